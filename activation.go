@@ -16,6 +16,10 @@ const (
 	ModeBinary Mode = 3
 	// ModeMultiLabel is for multilabel classification, applies sigmoid output layer
 	ModeMultiLabel Mode = 4
+	// ModeSurvival is time-to-event regression, applies linear output layer yielding a log-risk score
+	ModeSurvival Mode = 5
+	// ModeMixtureDensity is a mixture density network, applies linear output layer parameterizing Gaussian components
+	ModeMixtureDensity Mode = 6
 )
 
 // OutputActivation returns activation corresponding to prediction mode
@@ -23,7 +27,7 @@ func OutputActivation(c Mode) ActivationType {
 	switch c {
 	case ModeMultiClass:
 		return ActivationSoftmax
-	case ModeRegression:
+	case ModeRegression, ModeSurvival, ModeMixtureDensity:
 		return ActivationLinear
 	case ModeBinary, ModeMultiLabel:
 		return ActivationSigmoid
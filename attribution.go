@@ -0,0 +1,108 @@
+package deep
+
+import "fmt"
+
+// Saliency attributes output target of the prediction for input to the
+// inputs by the gradient of the output with respect to them. Like the other
+// attributions, it panics when input does not match the inputs of n or
+// target is not one of its outputs.
+func (n *Neural) Saliency(input []float64, target int) []float64 {
+	return n.inputGradients([][]float64{input}, target)[0]
+}
+
+// GradientInput attributes output target of the prediction for input to the
+// inputs by their products with its saliency
+func (n *Neural) GradientInput(input []float64, target int) []float64 {
+	attribution := n.Saliency(input, target)
+	for k, v := range input {
+		attribution[k] *= v
+	}
+	return attribution
+}
+
+// SmoothGrad attributes output target of the prediction for input to the
+// inputs by the saliency averaged over samples copies of input with gaussian
+// noise of standard deviation stdDev added. It panics unless samples is
+// positive.
+func (n *Neural) SmoothGrad(input []float64, target, samples int, stdDev float64) []float64 {
+	if samples < 1 {
+		panic(fmt.Sprintf("Invalid number of samples %d", samples))
+	}
+	r := n.random()
+	points := make([][]float64, samples)
+	for i := range points {
+		points[i] = make([]float64, len(input))
+		for k, v := range input {
+			points[i][k] = v + r.NormFloat64()*stdDev
+		}
+	}
+	return meanVector(n.inputGradients(points, target))
+}
+
+// IntegratedGradients attributes output target of the prediction for input
+// to the inputs by integrating the gradient along the straight path from
+// baseline to input, approximated with steps midpoints. A nil baseline is all
+// zeros. The attributions sum to the difference between the output for input
+// and for baseline, up to the approximation error. It panics unless steps is
+// positive.
+func (n *Neural) IntegratedGradients(input, baseline []float64, target, steps int) []float64 {
+	if steps < 1 {
+		panic(fmt.Sprintf("Invalid number of steps %d", steps))
+	}
+	if baseline == nil {
+		baseline = make([]float64, len(input))
+	}
+	points := make([][]float64, steps)
+	for i := range points {
+		alpha := (float64(i) + 0.5) / float64(steps)
+		points[i] = make([]float64, len(input))
+		for k, v := range input {
+			points[i][k] = baseline[k] + alpha*(v-baseline[k])
+		}
+	}
+	attribution := meanVector(n.inputGradients(points, target))
+	for k, v := range input {
+		attribution[k] *= v - baseline[k]
+	}
+	return attribution
+}
+
+// inputGradients returns the gradients of output target with respect to the
+// inputs at every point, all evaluated in the current recurrent state of n.
+// The recurrent state and the gradients accumulated by Backward are left as
+// they were.
+func (n *Neural) inputGradients(points [][]float64, target int) [][]float64 {
+	if outputs := len(n.Layers[len(n.Layers)-1].Neurons); target < 0 || target >= outputs {
+		panic(fmt.Sprintf("Invalid target %d - expected one of %d outputs", target, outputs))
+	}
+	for _, p := range points {
+		if len(p) != n.Config.Inputs {
+			panic(fmt.Sprintf("Invalid input dimension - expected: %d got: %d", n.Config.Inputs, len(p)))
+		}
+	}
+	state := n.RecurrentState()
+	gradients := n.Gradients()
+	outputGrad := make([]float64, len(n.Layers[len(n.Layers)-1].Neurons))
+	outputGrad[target] = 1
+
+	inputGradients := make([][]float64, len(points))
+	for i, p := range points {
+		n.SetRecurrentState(state)
+		n.Forward(p)
+		inputGradients[i] = n.Backward(outputGrad)
+	}
+	n.SetRecurrentState(state)
+	n.SetGradients(gradients)
+	return inputGradients
+}
+
+// meanVector is the elementwise mean of vectors
+func meanVector(vectors [][]float64) []float64 {
+	m := make([]float64, len(vectors[0]))
+	for _, v := range vectors {
+		for k := range m {
+			m[k] += v[k] / float64(len(vectors))
+		}
+	}
+	return m
+}
@@ -0,0 +1,61 @@
+package deep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Attribution(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs:     3,
+		Layout:     []int{5, 3},
+		Activation: ActivationTanh,
+		Mode:       ModeMultiClass,
+		Init:       &Initializer{Kind: "normal", Std: 0.8},
+		Bias:       true,
+		Seed:       1,
+	})
+	input, baseline, target := []float64{0.4, -1.1, 0.7}, []float64{0.1, 0.2, -0.3}, 1
+
+	// Fresh copies share the initial recurrent state
+	f := func(input []float64) float64 {
+		return FromDump(n.Dump()).Predict(input)[target]
+	}
+	n = FromDump(n.Dump())
+	n.Layers[0].Neurons[0].In[0].Gradient = 0.5
+	gradients := n.Gradients()
+
+	saliency := n.Saliency(input, target)
+	const h = 1e-6
+	for k := range input {
+		plus, minus := append([]float64{}, input...), append([]float64{}, input...)
+		plus[k] += h
+		minus[k] -= h
+		assert.InDelta(t, (f(plus)-f(minus))/(2*h), saliency[k], 1e-6)
+	}
+
+	gradientInput := n.GradientInput(input, target)
+	for k, v := range input {
+		assert.InDelta(t, saliency[k]*v, gradientInput[k], 1e-12)
+	}
+
+	assert.InDeltaSlice(t, saliency, n.SmoothGrad(input, target, 3, 0), 1e-12)
+	assert.NotEqual(t, saliency, n.SmoothGrad(input, target, 3, 0.1))
+
+	// Integrated gradients are complete
+	ig := n.IntegratedGradients(input, baseline, target, 100)
+	assert.InDelta(t, f(input)-f(baseline), Sum(ig), 1e-4)
+	ig = n.IntegratedGradients(input, nil, target, 100)
+	assert.InDelta(t, f(input)-f(make([]float64, len(input))), Sum(ig), 1e-4)
+
+	// Neither the recurrent state nor the gradients are disturbed
+	assert.Equal(t, gradients, n.Gradients())
+	assert.InDeltaSlice(t, saliency, n.Saliency(input, target), 1e-12)
+
+	assert.Panics(t, func() { n.SmoothGrad(input, target, 0, 0.1) })
+	assert.Panics(t, func() { n.IntegratedGradients(input, nil, target, 0) })
+	assert.Panics(t, func() { n.Saliency(input, -1) })
+	assert.Panics(t, func() { n.GradientInput(input, len(n.Layers[len(n.Layers)-1].Neurons)) })
+	assert.Panics(t, func() { n.Saliency(input[1:], target) })
+}
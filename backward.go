@@ -0,0 +1,105 @@
+package deep
+
+import "math"
+
+// Backward backpropagates outputGrad, the gradient of some function of the
+// outputs of the last forward pass with respect to those outputs. It adds the
+// gradient with respect to every weight to its Synapse.Gradient, and returns
+// the gradient with respect to the inputs. Backpropagation through recurrent
+// synapses is truncated to the last forward pass.
+func (n *Neural) Backward(outputGrad []float64) []float64 {
+	out := n.Layers[len(n.Layers)-1]
+	deltas := make([]float64, len(out.Neurons))
+	if out.A == ActivationSoftmax {
+		var dot float64
+		for i, neuron := range out.Neurons {
+			dot += outputGrad[i] * neuron.Value
+		}
+		for i, neuron := range out.Neurons {
+			deltas[i] = neuron.Value * (outputGrad[i] - dot)
+		}
+	} else {
+		for i, neuron := range out.Neurons {
+			deltas[i] = outputGrad[i] * neuron.DActivate(neuron.Value)
+		}
+	}
+	return n.BackwardDeltas(deltas)
+}
+
+// BackwardDeltas is Backward given the gradient with respect to the inputs of
+// the output activation, such as the derivatives of losses matched to it.
+// Deltas which are not a number are backpropagated as zero.
+func (n *Neural) BackwardDeltas(deltas []float64) []float64 {
+	last := len(n.Layers) - 1
+	for i, neuron := range n.Layers[last].Neurons {
+		neuron.delta = deltas[i]
+	}
+
+	for i := last - 1; i >= 0; i-- {
+		next := n.Layers[i+1].Neurons
+		for _, neuron := range n.Layers[i].Neurons {
+			var sum float64
+			for k, s := range neuron.Out[:len(next)] {
+				sum += s.weight() * next[k].delta
+			}
+			neuron.delta = neuron.DActivate(neuron.Value) * sum
+			if math.IsNaN(neuron.delta) {
+				neuron.delta = 0
+			}
+		}
+	}
+
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.Gradient += neuron.delta * s.In
+			}
+		}
+	}
+
+	inputGrad := make([]float64, n.Config.Inputs)
+	for _, neuron := range n.Layers[0].Neurons {
+		for k := range inputGrad {
+			inputGrad[k] += neuron.In[k].weight() * neuron.delta
+		}
+	}
+	return inputGrad
+}
+
+// Gradients returns the gradients accumulated by Backward, laid out like Weights
+func (n Neural) Gradients() [][][]float64 {
+	gradients := make([][][]float64, len(n.Layers))
+	for i, l := range n.Layers {
+		gradients[i] = make([][]float64, len(l.Neurons))
+		for j, neuron := range l.Neurons {
+			gradients[i][j] = make([]float64, len(neuron.In))
+			for k, s := range neuron.In {
+				gradients[i][j][k] = s.Gradient
+			}
+		}
+	}
+	return gradients
+}
+
+// SetGradients sets the gradients accumulated by Backward to gradients, laid
+// out like Weights
+func (n *Neural) SetGradients(gradients [][][]float64) {
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				s.Gradient = gradients[i][j][k]
+			}
+		}
+	}
+}
+
+// ZeroGradients resets the gradients accumulated by Backward
+func (n *Neural) ZeroGradients() {
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.Gradient = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package deep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Backward(t *testing.T) {
+	for _, mode := range []Mode{ModeMultiClass, ModeMultiLabel, ModeRegression} {
+		n := NewNeural(&Config{
+			Inputs:     3,
+			Layout:     []int{4, 3, 2},
+			Activation: ActivationTanh,
+			Mode:       mode,
+			Init:       &Initializer{Kind: "normal", Std: 0.8},
+			Bias:       true,
+			Seed:       1,
+		})
+		input, grad := []float64{0.4, -1.1, 0.7}, []float64{0.3, -0.9}
+
+		// Fresh copies share the initial recurrent state
+		f := func(n *Neural, input []float64) float64 {
+			return Dot(grad, FromDump(n.Dump()).Predict(input))
+		}
+		n = FromDump(n.Dump())
+		n.Forward(input)
+		inputGrad := n.Backward(grad)
+
+		const h = 1e-6
+		for k := range input {
+			plus, minus := append([]float64{}, input...), append([]float64{}, input...)
+			plus[k] += h
+			minus[k] -= h
+			assert.InDelta(t, (f(n, plus)-f(n, minus))/(2*h), inputGrad[k], 1e-6)
+		}
+
+		gradients := n.Gradients()
+		for i, l := range n.Layers {
+			for j, neuron := range l.Neurons {
+				for k, s := range neuron.In {
+					if i < len(n.Layers)-1 && k == len(neuron.In)-2 {
+						// Recurrent synapses are truncated
+						continue
+					}
+					w := s.Weight
+					s.Weight = w + h
+					plus := f(n, input)
+					s.Weight = w - h
+					minus := f(n, input)
+					s.Weight = w
+					assert.InDelta(t, (plus-minus)/(2*h), gradients[i][j][k], 1e-6)
+				}
+			}
+		}
+
+		// Gradients accumulate until reset
+		n.Backward(grad)
+		assert.InDelta(t, 2*gradients[0][0][0], n.Gradients()[0][0][0], 1e-12)
+		n.ZeroGradients()
+		assert.Equal(t, 0.0, n.Gradients()[0][0][0])
+	}
+}
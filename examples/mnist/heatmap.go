@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+const (
+	side  = 28
+	scale = 8
+)
+
+// explain renders the digit input next to heatmaps of the attributions of its
+// predicted class to its pixels, in red where they raise the prediction and in
+// blue where they lower it
+func explain(n *deep.Neural, input []float64, path string) error {
+	target := deep.ArgMax(n.Predict(input))
+	panels := [][]float64{
+		input,
+		n.Saliency(input, target),
+		n.GradientInput(input, target),
+		n.SmoothGrad(input, target, 50, 0.15),
+		n.IntegratedGradients(input, nil, target, 50),
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, len(panels)*side*scale, side*scale))
+	for p, values := range panels {
+		max := 0.
+		for _, v := range values {
+			max = math.Max(max, math.Abs(v))
+		}
+		for k, v := range values {
+			var c color.RGBA
+			if p == 0 {
+				g := uint8(255 * (1 - v))
+				c = color.RGBA{g, g, g, 255}
+			} else {
+				c = diverging(v / max)
+			}
+			x, y := p*side+k%side, k/side
+			for i := 0; i < scale; i++ {
+				for j := 0; j < scale; j++ {
+					img.Set(x*scale+i, y*scale+j, c)
+				}
+			}
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Printf("%s: predicted %d (digit, saliency, gradient×input, smoothgrad, integrated gradients)\n", path, target)
+	return png.Encode(f, img)
+}
+
+// diverging maps v in [-1, 1] from blue through white to red
+func diverging(v float64) color.RGBA {
+	if math.IsNaN(v) {
+		v = 0
+	}
+	fade := uint8(255 * (1 - math.Abs(v)))
+	if v > 0 {
+		return color.RGBA{255, fade, fade, 255}
+	}
+	return color.RGBA{fade, fade, 255, 255}
+}
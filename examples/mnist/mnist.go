@@ -51,7 +51,7 @@ func main() {
 		Layout:     []int{50, 10},
 		Activation: deep.ActivationReLU,
 		Mode:       deep.ModeMultiClass,
-		Weight:     deep.NewNormal(0.6, 0.1), // slight positive bias helps ReLU
+		Init:       &deep.Initializer{Kind: "he"},
 		Bias:       true,
 	})
 
@@ -61,6 +61,19 @@ func main() {
 	fmt.Printf("training: %d, val: %d, test: %d\n", len(train), len(test), len(test))
 
 	trainer.Train(neural, train, test, 500)
+
+	fgsm := func(epsilon float64) training.Attack {
+		return training.FGSM{Epsilon: epsilon, Min: 0, Max: 1}
+	}
+	for _, r := range training.EvaluateRobustness(neural, test[:1000], fgsm, []float64{0, 0.02, 0.05, 0.1}) {
+		fmt.Printf("FGSM ε=%.2f: accuracy %.3f\n", r.Epsilon, r.Accuracy)
+	}
+
+	for i, e := range test[:5] {
+		if err := explain(neural, e.Input, fmt.Sprintf("explain-%d.png", i)); err != nil {
+			panic(err)
+		}
+	}
 }
 
 func load(path string) (training.Examples, error) {
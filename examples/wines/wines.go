@@ -12,6 +12,7 @@ import (
 
 	"github.com/Maxime2/go-deep"
 	"github.com/Maxime2/go-deep/training"
+	"github.com/Maxime2/go-deep/training/explain"
 )
 
 func main() {
@@ -35,7 +36,7 @@ func main() {
 		Layout:     []int{8, 3},
 		Activation: deep.ActivationTanh,
 		Mode:       deep.ModeMultiClass,
-		Weight:     deep.NewNormal(1, 0),
+		Init:       &deep.Initializer{Kind: "normal", Std: 1},
 		Bias:       true,
 	})
 
@@ -45,6 +46,28 @@ func main() {
 	trainer := training.NewBatchTrainer(training.NewAdam(0.1, 0, 0, 0), 50, len(data)/2, 12)
 	//data, heldout := data.Split(0.5)
 	trainer.Train(neural, data, data, 5000)
+
+	wines := training.Dataset{Examples: data, Features: features}
+	for _, importance := range explain.New(neural, wines, nil).PermutationImportance(10) {
+		fmt.Printf("%-30s %.4f ± %.4f\n", importance.Feature, importance.Mean, importance.StdDev)
+	}
+}
+
+// features names the attributes of the wines, as listed in wine.description
+var features = []string{
+	"Alcohol",
+	"Malic acid",
+	"Ash",
+	"Alcalinity of ash",
+	"Magnesium",
+	"Total phenols",
+	"Flavanoids",
+	"Nonflavanoid phenols",
+	"Proanthocyanins",
+	"Color intensity",
+	"Hue",
+	"OD280/OD315 of diluted wines",
+	"Proline",
 }
 
 func load(path string) (training.Examples, error) {
@@ -0,0 +1,198 @@
+package deep
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SingularValues returns the singular values of the feed-forward weights of
+// layer i in decreasing order
+func SingularValues(n *Neural, i int) []float64 {
+	_, s, _ := svd(feedForward(n, i))
+	return s
+}
+
+// EnergyRank returns the smallest rank whose singular values retain the given
+// fraction of the sum of squares of all singular values
+func EnergyRank(singular []float64, energy float64) int {
+	var total float64
+	for _, s := range singular {
+		total += s * s
+	}
+	var retained float64
+	for r, s := range singular {
+		retained += s * s
+		if retained >= energy*total {
+			return r + 1
+		}
+	}
+	return len(singular)
+}
+
+// Factorize returns a copy of n in which the feed-forward weights W of layer i
+// are replaced by a truncated SVD of the given rank W ≈ UΣVᵀ, inserting a
+// linear layer of rank neurons computing √ΣVᵀx before layer i, whose weights
+// become U√Σ. The new layer has neither recurrent nor bias synapses, which are
+// pruned.
+func Factorize(n *Neural, i, rank int) (*Neural, error) {
+	if i < 0 || i >= len(n.Layers) {
+		return nil, fmt.Errorf("Invalid layer %d", i)
+	}
+	w := feedForward(n, i)
+	if rank < 1 || rank > len(w) || rank > len(w[0]) {
+		return nil, fmt.Errorf("Invalid rank %d for a %dx%d layer", rank, len(w), len(w[0]))
+	}
+	u, s, v := svd(w)
+
+	c := *n.Config
+	c.Layout = make([]int, 0, len(n.Config.Layout)+1)
+	c.Activations = make([]ActivationType, 0, len(n.Config.Layout)+1)
+	for j, size := range n.Config.Layout {
+		if j == i {
+			c.Layout = append(c.Layout, rank)
+			c.Activations = append(c.Activations, ActivationLinear)
+		}
+		c.Layout = append(c.Layout, size)
+		c.Activations = append(c.Activations, n.Config.LayerActivation(j))
+	}
+
+	weights, masks := n.Weights(), n.Masks()
+	bottleneck := make([][]float64, rank)
+	bottleneckMasks := make([][]bool, rank)
+	for k := range bottleneck {
+		scale := math.Sqrt(s[k])
+		bottleneck[k] = make([]float64, len(v[k]), len(v[k])+2)
+		for j := range v[k] {
+			bottleneck[k][j] = scale * v[k][j]
+		}
+		// Recurrent and bias synapses
+		bottleneck[k] = append(bottleneck[k], 0)
+		if c.Bias {
+			bottleneck[k] = append(bottleneck[k], 0)
+		}
+		bottleneckMasks[k] = make([]bool, len(bottleneck[k]))
+		for j := len(v[k]); j < len(bottleneck[k]); j++ {
+			bottleneckMasks[k][j] = true
+		}
+	}
+	for j := range weights[i] {
+		factor := make([]float64, rank, rank+len(weights[i][j])-len(w[j]))
+		for k := range factor {
+			factor[k] = u[k][j] * math.Sqrt(s[k])
+		}
+		weights[i][j] = append(factor, weights[i][j][len(w[j]):]...)
+		masks[i][j] = append(make([]bool, rank), masks[i][j][len(w[j]):]...)
+	}
+	weights = append(weights[:i:i], append([][][]float64{bottleneck}, weights[i:]...)...)
+	masks = append(masks[:i:i], append([][][]bool{bottleneckMasks}, masks[i:]...)...)
+
+	f := NewNeural(&c)
+	f.ApplyWeights(weights)
+	f.ApplyMasks(masks)
+	return f, nil
+}
+
+// feedForward returns the feed-forward weights of layer i, one row per neuron
+func feedForward(n *Neural, i int) [][]float64 {
+	fanIn := n.Config.Inputs
+	if i > 0 {
+		fanIn = len(n.Layers[i-1].Neurons)
+	}
+	w := make([][]float64, len(n.Layers[i].Neurons))
+	for j, neuron := range n.Layers[i].Neurons {
+		w[j] = make([]float64, fanIn)
+		for k := range w[j] {
+			w[j][k] = neuron.In[k].Weight
+		}
+	}
+	return w
+}
+
+// svd computes the singular value decomposition a = UΣVᵀ of an m×n matrix by
+// one-sided Jacobi rotations, returning the min(m, n) left singular vectors
+// u[k], singular values s[k] in decreasing order and right singular vectors v[k]
+func svd(a [][]float64) (u [][]float64, s []float64, v [][]float64) {
+	m, n := len(a), len(a[0])
+	if m < n {
+		// Orthogonalize the fewer columns of aᵀ = VΣUᵀ
+		t := make([][]float64, n)
+		for j := range t {
+			t[j] = make([]float64, m)
+			for i := range t[j] {
+				t[j][i] = a[i][j]
+			}
+		}
+		v, s, u = svd(t)
+		return
+	}
+
+	// Columns of a, which are rotated into UΣ while the identity becomes V
+	cols := make([][]float64, n)
+	v = make([][]float64, n)
+	for j := range cols {
+		cols[j] = make([]float64, m)
+		for i := range cols[j] {
+			cols[j][i] = a[i][j]
+		}
+		v[j] = make([]float64, n)
+		v[j][j] = 1
+	}
+
+	for sweep := 0; sweep < 60; sweep++ {
+		rotated := false
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				var alpha, beta, gamma float64
+				for i := range cols[p] {
+					alpha += cols[p][i] * cols[p][i]
+					beta += cols[q][i] * cols[q][i]
+					gamma += cols[p][i] * cols[q][i]
+				}
+				if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				rotate(cols[p], cols[q], c, s)
+				rotate(v[p], v[q], c, s)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	s = make([]float64, n)
+	for j, col := range cols {
+		for _, x := range col {
+			s[j] += x * x
+		}
+		s[j] = math.Sqrt(s[j])
+		if s[j] > 0 {
+			for i := range col {
+				col[i] /= s[j]
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for j := range order {
+		order[j] = j
+	}
+	sort.SliceStable(order, func(a, b int) bool { return s[order[a]] > s[order[b]] })
+	u, sorted, vectors := make([][]float64, n), make([]float64, n), make([][]float64, n)
+	for j, k := range order {
+		u[j], sorted[j], vectors[j] = cols[k], s[k], v[k]
+	}
+	return u, sorted, vectors
+}
+
+func rotate(x, y []float64, c, s float64) {
+	for i := range x {
+		x[i], y[i] = c*x[i]-s*y[i], s*x[i]+c*y[i]
+	}
+}
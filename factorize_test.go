@@ -0,0 +1,97 @@
+package deep
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SVD(t *testing.T) {
+	rand.Seed(0)
+	for _, shape := range [][3]int{{6, 4, 4}, {3, 7, 3}, {5, 5, 5}} {
+		a := randomInputs(shape[0], shape[1])
+		u, s, v := svd(a)
+		assert.Len(t, s, shape[2])
+
+		for k := 1; k < len(s); k++ {
+			assert.True(t, s[k-1] >= s[k])
+		}
+		for i := range a {
+			for j := range a[i] {
+				var x float64
+				for k := range s {
+					x += u[k][i] * s[k] * v[k][j]
+				}
+				assert.InDelta(t, a[i][j], x, 1e-9)
+			}
+		}
+		for p := range v {
+			for q := range v {
+				var dot float64
+				for j := range v[p] {
+					dot += v[p][j] * v[q][j]
+				}
+				if p == q {
+					assert.InDelta(t, 1, dot, 1e-9)
+				} else {
+					assert.InDelta(t, 0, dot, 1e-9)
+				}
+			}
+		}
+	}
+}
+
+func Test_EnergyRank(t *testing.T) {
+	s := []float64{3, 2, 1, 0}
+	assert.Equal(t, 1, EnergyRank(s, 0.5))
+	assert.Equal(t, 2, EnergyRank(s, 0.9))
+	assert.Equal(t, 3, EnergyRank(s, 1))
+}
+
+func Test_Factorize(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs:     7,
+		Layout:     []int{5, 4, 3},
+		Activation: ActivationTanh,
+		Mode:       ModeMultiClass,
+		Init:       &Initializer{Kind: "glorot_normal"},
+		Bias:       true,
+		Seed:       1,
+	})
+
+	for i := range n.Layers {
+		reference := FromDump(n.Dump())
+		rank := len(SingularValues(n, i))
+		f, err := Factorize(n, i, rank)
+		assert.NoError(t, err)
+
+		assert.Len(t, f.Layers, len(n.Layers)+1)
+		assert.Equal(t, rank, f.Config.Layout[i])
+		assert.Equal(t, ActivationLinear, f.Layers[i].A)
+		assert.Equal(t, ActivationSoftmax, f.Layers[len(f.Layers)-1].A)
+		// Recurrent and bias synapses of the inserted layer are pruned
+		for _, neuron := range f.Layers[i].Neurons {
+			for _, s := range neuron.In[len(neuron.In)-2:] {
+				assert.True(t, s.Pruned)
+			}
+		}
+
+		restored := FromDump(f.Dump())
+		for j := 0; j < 5; j++ {
+			x := randomInputs(1, 7)[0]
+			y := reference.Predict(x)
+			assert.InDeltaSlice(t, y, f.Predict(x), 1e-9)
+			assert.InDeltaSlice(t, y, restored.Predict(x), 1e-9)
+		}
+	}
+
+	// The original network is left untouched
+	assert.Equal(t, []int{5, 4, 3}, n.Config.Layout)
+	assert.Nil(t, n.Config.Activations)
+
+	_, err := Factorize(n, 0, 6)
+	assert.Error(t, err)
+	_, err = Factorize(n, 3, 1)
+	assert.Error(t, err)
+}
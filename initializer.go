@@ -0,0 +1,60 @@
+package deep
+
+import "fmt"
+
+// Initializer describes a weight initializer as data, so that it survives
+// serialization of a Config, e.g. {"kind":"normal","std":0.6,"mean":0.1}.
+//
+// Built-in kinds are "uniform" and "normal" (using Std and Mean),
+// "glorot_uniform", "glorot_normal", "he", "lecun" and "orthogonal", which
+// initializes the weights between layers as orthogonal matrices scaled by
+// Gain (default 1) and all others as "glorot_uniform". Further kinds can be
+// added with RegisterInitializer.
+type Initializer struct {
+	Kind string  `json:"kind"`
+	Std  float64 `json:"std,omitempty"`
+	Mean float64 `json:"mean,omitempty"`
+	Gain float64 `json:"gain,omitempty"`
+	// Parameters of custom kinds
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+var initializers = map[string]func(Initializer) WeightInitializer{
+	"uniform":        func(i Initializer) WeightInitializer { return NewUniform(i.Std, i.Mean) },
+	"normal":         func(i Initializer) WeightInitializer { return NewNormal(i.Std, i.Mean) },
+	"glorot_uniform": func(Initializer) WeightInitializer { return NewGlorotUniform() },
+	"glorot_normal":  func(Initializer) WeightInitializer { return NewGlorotNormal() },
+	"he":             func(Initializer) WeightInitializer { return NewHe() },
+	"lecun":          func(Initializer) WeightInitializer { return NewLeCun() },
+	"orthogonal":     func(Initializer) WeightInitializer { return NewGlorotUniform() },
+}
+
+// RegisterInitializer makes a custom initializer available to Initializers of
+// the given kind, replacing any previous one. It is not safe to call
+// concurrently with NewNeural and is best called from an init function.
+func RegisterInitializer(kind string, f func(Initializer) WeightInitializer) {
+	initializers[kind] = f
+}
+
+// WeightInitializer returns the weight initializer described by i
+func (i Initializer) WeightInitializer() (WeightInitializer, error) {
+	f, ok := initializers[i.Kind]
+	if !ok {
+		return nil, fmt.Errorf("Unknown weight initializer kind: %q", i.Kind)
+	}
+	return f(i), nil
+}
+
+// apply runs the initialization of i which is not expressed per weight
+func (i Initializer) apply(n *Neural) {
+	if i.Kind == "orthogonal" {
+		InitOrthogonal(n, fparam(i.Gain, 1))
+	}
+}
+
+func fparam(val, fallback float64) float64 {
+	if val == 0.0 {
+		return fallback
+	}
+	return val
+}
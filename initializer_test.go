@@ -0,0 +1,100 @@
+package deep
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InitializerRoundTrip(t *testing.T) {
+	rand.Seed(0)
+
+	n := NewNeural(&Config{
+		Inputs: 2,
+		Layout: []int{3, 1},
+		Init:   &Initializer{Kind: "normal", Std: 0.6, Mean: 0.1},
+		Bias:   true,
+	})
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+	assert.Contains(t, string(dump), `"Init":{"kind":"normal","std":0.6,"mean":0.1}`)
+
+	new, err := Unmarshal(dump)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Config.Init, new.Config.Init)
+	assert.Equal(t, n.Weights(), new.Weights())
+
+	// Networks recreated from the config draw from the same distribution
+	rand.Seed(0)
+	fresh := NewNeural(&Config{Inputs: 2, Layout: []int{3, 1}, Bias: true, Init: new.Config.Init})
+	assert.Equal(t, n.Weights(), fresh.Weights())
+}
+
+func Test_DefaultInitializer(t *testing.T) {
+	c := &Config{Inputs: 1, Layout: []int{1}}
+	NewNeural(c)
+	assert.Equal(t, &Initializer{Kind: "uniform", Std: 0.5}, c.Init)
+
+	b, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"Init":{"kind":"uniform","std":0.5}`)
+}
+
+func Test_OrthogonalInitializer(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs: 4,
+		Layout: []int{4, 1},
+		Init:   &Initializer{Kind: "orthogonal", Gain: 3},
+	})
+
+	w := n.Weights()[0]
+	for i := range w {
+		assert.InDelta(t, 9, Dot(w[i][:4], w[i][:4]), 1e-9)
+	}
+
+	// A custom initializer takes precedence over the orthogonal one
+	n = NewNeural(&Config{
+		Inputs: 2,
+		Layout: []int{2},
+		Init:   &Initializer{Kind: "orthogonal"},
+		Weight: func(_ *rand.Rand, _, _ int) float64 { return 0.5 },
+	})
+	assert.Equal(t, [][][]float64{{{0.5, 0.5}, {0.5, 0.5}}}, n.Weights())
+}
+
+func Test_UnknownInitializer(t *testing.T) {
+	c := &Config{Inputs: 1, Layout: []int{1}, Init: &Initializer{Kind: "unknown"}}
+	_, err := New(c)
+	assert.Error(t, err)
+	assert.Panics(t, func() { NewNeural(c) })
+
+	c.Weight = NewUniform(1, 0)
+	n, err := New(c)
+	assert.NoError(t, err)
+	assert.NotNil(t, n)
+}
+
+func Test_CustomInitializer(t *testing.T) {
+	RegisterInitializer("constant", func(i Initializer) WeightInitializer {
+		return func(_ *rand.Rand, _, _ int) float64 { return i.Params["value"] }
+	})
+
+	n := NewNeural(&Config{
+		Inputs: 2,
+		Layout: []int{2},
+		Init:   &Initializer{Kind: "constant", Params: map[string]float64{"value": 0.25}},
+	})
+	assert.Equal(t, [][][]float64{{{0.25, 0.25}, {0.25, 0.25}}}, n.Weights())
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+	new, err := Unmarshal(dump)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Config.Init, new.Config.Init)
+
+	_, err = Unmarshal([]byte(`{"Config":{"Inputs":1,"Layout":[1],"Init":{"kind":"unknown"}}}`))
+	assert.Error(t, err)
+}
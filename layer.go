@@ -1,6 +1,9 @@
 package deep
 
-import "fmt"
+import (
+	"fmt"
+	"math/rand"
+)
 
 // Layer is a set of neurons and corresponding activation
 type Layer struct {
@@ -42,26 +45,28 @@ func (l *Layer) fire() {
 }
 
 // Connect fully connects layer l to next, and initializes each
-// synapse with the given weight function
-func (l *Layer) Connect(next *Layer, weight WeightInitializer) {
+// synapse with the given weight function drawing from r. Recurrent
+// synapses are initialized as a square matrix connecting l to itself.
+func (l *Layer) Connect(next *Layer, r *rand.Rand, weight WeightInitializer) {
 	for i := range l.Neurons {
 		for j := range next.Neurons {
-			syn := NewSynapse(weight())
+			syn := NewSynapse(weight(r, len(l.Neurons), len(next.Neurons)))
 			l.Neurons[i].Out = append(l.Neurons[i].Out, syn)
 			next.Neurons[j].In = append(next.Neurons[j].In, syn)
 		}
 		// Add recurrent synapse
-		syn := NewSynapse(weight())
+		syn := NewSynapse(weight(r, len(l.Neurons), len(l.Neurons)))
 		l.Neurons[i].Out = append(l.Neurons[i].Out, syn)
 		l.Neurons[i].In = append(l.Neurons[i].In, syn)
 	}
 }
 
-// ApplyBias creates and returns a bias synapse for each neuron in l
-func (l *Layer) ApplyBias(weight WeightInitializer) []*Synapse {
+// ApplyBias creates and returns a bias synapse for each neuron in l,
+// where fanIn is the number of inputs feeding l
+func (l *Layer) ApplyBias(fanIn int, r *rand.Rand, weight WeightInitializer) []*Synapse {
 	biases := make([]*Synapse, len(l.Neurons))
 	for i := range l.Neurons {
-		biases[i] = NewSynapse(weight())
+		biases[i] = NewSynapse(weight(r, fanIn, len(l.Neurons)))
 		biases[i].IsBias = true
 		l.Neurons[i].In = append(l.Neurons[i].In, biases[i])
 	}
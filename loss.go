@@ -1,7 +1,9 @@
 package deep
 
 import (
+	"fmt"
 	"math"
+	"sort"
 )
 
 // GetLoss returns a loss function given a LossType
@@ -13,6 +15,10 @@ func GetLoss(loss LossType) Loss {
 		return MeanSquared{}
 	case LossBinaryCrossEntropy:
 		return BinaryCrossEntropy{}
+	case LossCox:
+		return Cox{}
+	case LossMixtureDensity:
+		return MixtureDensity{}
 	}
 	return CrossEntropy{}
 }
@@ -28,6 +34,10 @@ func (l LossType) String() string {
 		return "BinCE"
 	case LossMeanSquared:
 		return "MSE"
+	case LossCox:
+		return "Cox"
+	case LossMixtureDensity:
+		return "MDN"
 	}
 	return "N/A"
 }
@@ -41,30 +51,60 @@ const (
 	LossBinaryCrossEntropy LossType = 2
 	// LossMeanSquared is MSE
 	LossMeanSquared LossType = 3
+	// LossCox is the Cox proportional hazards negative partial log-likelihood
+	LossCox LossType = 4
+	// LossMixtureDensity is the negative log-likelihood of a Gaussian mixture
+	LossMixtureDensity LossType = 5
 )
 
-// Loss is satisfied by loss functions
+// Loss is satisfied by loss functions. Every example i is weighted by
+// weights[i] and only targets j where mask[i][j] holds contribute to the loss.
+// Nil weights weigh all examples equally and nil masks include all targets.
 type Loss interface {
-	F(estimate, ideal [][]float64) float64
+	F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64
 	Df(estimate, ideal, activation float64) float64
 }
 
+// BatchLoss is satisfied by loss functions that couple the examples of a batch,
+// so that their gradient can only be computed over the batch as a whole
+type BatchLoss interface {
+	Loss
+	// BatchDf returns the gradient of the summed, weighted loss with respect to every estimate
+	BatchDf(estimate, ideal [][]float64, weights []float64, mask [][]bool) [][]float64
+}
+
+// weight is the weight of the i-th example
+func weight(weights []float64, i int) float64 {
+	if weights == nil {
+		return 1
+	}
+	return weights[i]
+}
+
+// observed reports whether the j-th target of the i-th example is unmasked
+func observed(mask [][]bool, i, j int) bool {
+	return mask == nil || mask[i] == nil || mask[i][j]
+}
+
 // CrossEntropy is CE loss
 type CrossEntropy struct{}
 
 // F is CE(...)
-func (l CrossEntropy) F(estimate, ideal [][]float64) float64 {
+func (l CrossEntropy) F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64 {
 
-	var sum float64
+	var sum, total float64
 	for i := range estimate {
 		ce := 0.0
 		for j := range estimate[i] {
-			ce += ideal[i][j] * math.Log(estimate[i][j])
+			if observed(mask, i, j) {
+				ce += ideal[i][j] * math.Log(estimate[i][j])
+			}
 		}
 
-		sum -= ce
+		sum -= weight(weights, i) * ce
+		total += weight(weights, i)
 	}
-	return sum / float64(len(estimate))
+	return sum / total
 }
 
 // Df is CE'(...)
@@ -76,17 +116,20 @@ func (l CrossEntropy) Df(estimate, ideal, activation float64) float64 {
 type BinaryCrossEntropy struct{}
 
 // F is CE(...)
-func (l BinaryCrossEntropy) F(estimate, ideal [][]float64) float64 {
+func (l BinaryCrossEntropy) F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64 {
 	epsilon := 1e-16
-	var sum float64
+	var sum, total float64
 	for i := range estimate {
 		ce := 0.0
 		for j := range estimate[i] {
-			ce += ideal[i][j]*math.Log(estimate[i][j]+epsilon) + (1.0-ideal[i][j])*math.Log(1.0-estimate[i][j]+epsilon)
+			if observed(mask, i, j) {
+				ce += ideal[i][j]*math.Log(estimate[i][j]+epsilon) + (1.0-ideal[i][j])*math.Log(1.0-estimate[i][j]+epsilon)
+			}
 		}
-		sum -= ce
+		sum -= weight(weights, i) * ce
+		total += weight(weights, i)
 	}
-	return sum / float64(len(estimate))
+	return sum / total
 }
 
 // Df is CE'(...)
@@ -98,17 +141,193 @@ func (l BinaryCrossEntropy) Df(estimate, ideal, activation float64) float64 {
 type MeanSquared struct{}
 
 // F is MSE(...)
-func (l MeanSquared) F(estimate, ideal [][]float64) float64 {
-	var sum float64
+func (l MeanSquared) F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64 {
+	var sum, total float64
 	for i := 0; i < len(estimate); i++ {
 		for j := 0; j < len(estimate[i]); j++ {
-			sum += math.Pow(estimate[i][j]-ideal[i][j], 2)
+			if observed(mask, i, j) {
+				sum += weight(weights, i) * math.Pow(estimate[i][j]-ideal[i][j], 2)
+				total += weight(weights, i)
+			}
 		}
 	}
-	return sum / float64(len(estimate)*len(estimate[0]))
+	return sum / total
 }
 
 // Df is MSE'(...)
 func (l MeanSquared) Df(estimate, ideal, activation float64) float64 {
 	return activation * (estimate - ideal)
 }
+
+// Cox is the negative partial log-likelihood of the Cox proportional hazards
+// model, using Breslow's method for tied times. Estimates are log-risk scores
+// and each ideal is a (time, event) pair, where event is 1 for an observed
+// event and 0 for a censored observation. Examples with any masked target
+// are left out altogether.
+type Cox struct{}
+
+// F is the partial log-likelihood averaged over observed events
+func (l Cox) F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64 {
+	w := coxWeights(len(estimate), weights, mask)
+	order, risk := coxRiskSets(estimate, ideal, w)
+
+	var sum, events float64
+	for _, i := range order {
+		if ideal[i][1] == 0 || w[i] == 0 {
+			continue
+		}
+		sum -= w[i] * (estimate[i][0] - risk[i])
+		events += w[i]
+	}
+	if events == 0 {
+		return 0
+	}
+	return sum / events
+}
+
+// Df is the gradient over a batch of a single example, which is always zero
+func (l Cox) Df(estimate, ideal, activation float64) float64 {
+	return 0
+}
+
+// BatchDf is Cox'(...)
+func (l Cox) BatchDf(estimate, ideal [][]float64, weights []float64, mask [][]bool) [][]float64 {
+	w := coxWeights(len(estimate), weights, mask)
+	order, risk := coxRiskSets(estimate, ideal, w)
+
+	grad := make([][]float64, len(estimate))
+	var cum float64
+	for a := 0; a < len(order); {
+		b := a
+		for b < len(order) && ideal[order[b]][0] == ideal[order[a]][0] {
+			if i := order[b]; ideal[i][1] != 0 && w[i] != 0 {
+				cum += w[i] * math.Exp(-risk[i])
+			}
+			b++
+		}
+		for _, i := range order[a:b] {
+			grad[i] = []float64{w[i] * (math.Exp(estimate[i][0])*cum - ideal[i][1])}
+		}
+		a = b
+	}
+	return grad
+}
+
+// coxWeights is the weight of every example, which is zero when masked
+func coxWeights(n int, weights []float64, mask [][]bool) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		if observed(mask, i, 0) && observed(mask, i, 1) {
+			w[i] = weight(weights, i)
+		}
+	}
+	return w
+}
+
+// coxRiskSets orders examples by ascending time and returns, for each example,
+// the log of the weighted risk of all examples still at risk at its time
+func coxRiskSets(estimate, ideal [][]float64, w []float64) ([]int, []float64) {
+	order := make([]int, len(estimate))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return ideal[order[a]][0] < ideal[order[b]][0]
+	})
+
+	max := math.Inf(-1)
+	for _, e := range estimate {
+		max = math.Max(max, e[0])
+	}
+
+	risk := make([]float64, len(estimate))
+	var sum float64
+	for b := len(order); b > 0; {
+		a := b - 1
+		for a > 0 && ideal[order[a-1]][0] == ideal[order[b-1]][0] {
+			a--
+		}
+		for _, i := range order[a:b] {
+			sum += w[i] * math.Exp(estimate[i][0]-max)
+		}
+		for _, i := range order[a:b] {
+			risk[i] = math.Log(sum) + max
+		}
+		b = a
+	}
+	return order, risk
+}
+
+// MixtureDensity is the negative log-likelihood of a mixture of Gaussians,
+// whose parameters are the estimates as laid out by NewMixture. The number
+// of components is inferred from the number of estimates and ideals. Masked
+// targets are marginalized out of the likelihood.
+type MixtureDensity struct{}
+
+// F is the average negative log-likelihood
+func (l MixtureDensity) F(estimate, ideal [][]float64, weights []float64, mask [][]bool) float64 {
+	var sum, total float64
+	for i := range estimate {
+		sum -= weight(weights, i) * logSumExp(mixtureComponents(estimate[i], ideal[i], mask, i))
+		total += weight(weights, i)
+	}
+	return sum / total
+}
+
+// Df is undefined for single parameters of a mixture and always zero
+func (l MixtureDensity) Df(estimate, ideal, activation float64) float64 {
+	return 0
+}
+
+// BatchDf is the gradient of the negative log-likelihood of every example
+// with respect to the logits, means and log-variances of its mixture
+func (l MixtureDensity) BatchDf(estimate, ideal [][]float64, weights []float64, mask [][]bool) [][]float64 {
+	grad := make([][]float64, len(estimate))
+	for i, out := range estimate {
+		dim := len(ideal[i])
+		k := len(out) / (2*dim + 1)
+		pi := Softmax(out[:k])
+		w := weight(weights, i)
+
+		// Posterior responsibility of each component for the ideal
+		resp := Softmax(mixtureComponents(out, ideal[i], mask, i))
+
+		grad[i] = make([]float64, len(out))
+		for c := 0; c < k; c++ {
+			grad[i][c] = w * (pi[c] - resp[c])
+			for d, y := range ideal[i] {
+				if !observed(mask, i, d) {
+					continue
+				}
+				mean, logVar := k+c*dim+d, k+k*dim+c*dim+d
+				z := (y - out[mean]) * (y - out[mean]) * math.Exp(-out[logVar])
+				grad[i][mean] = -w * resp[c] * (y - out[mean]) * math.Exp(-out[logVar])
+				grad[i][logVar] = 0.5 * w * resp[c] * (1 - z)
+			}
+		}
+	}
+	return grad
+}
+
+// mixtureComponents returns the joint log density of every mixture component
+// and the unmasked targets of the i-th example. It panics unless out holds
+// the parameters of whole components for targets of the dimension of ideal.
+func mixtureComponents(out, ideal []float64, mask [][]bool, i int) []float64 {
+	dim := len(ideal)
+	if len(out)%(2*dim+1) != 0 {
+		panic(fmt.Sprintf("Invalid mixture density outputs %d for %d-dimensional targets", len(out), dim))
+	}
+	k := len(out) / (2*dim + 1)
+	pi := Softmax(out[:k])
+
+	logs := make([]float64, k)
+	for c := range logs {
+		logs[c] = math.Log(pi[c])
+		for d, y := range ideal {
+			if observed(mask, i, d) {
+				logs[c] += logNormal(y, out[k+c*dim+d], out[k+k*dim+c*dim+d])
+			}
+		}
+	}
+	return logs
+}
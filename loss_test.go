@@ -2,6 +2,7 @@ package deep
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,11 +33,64 @@ func Test_Loss(t *testing.T) {
 			target: [][]float64{{0.5}},
 			res:    0.69,
 		},
+		{
+			loss:   LossCox,
+			input:  [][]float64{{0}, {0}, {1}},
+			target: [][]float64{{1, 1}, {2, 1}, {3, 0}},
+			res:    1.43,
+		},
 	}
 	for _, test := range tests {
 		loss := GetLoss(test.loss)
-		estimate := loss.F(test.input, test.target)
+		estimate := loss.F(test.input, test.target, nil, nil)
 		assert.InEpsilon(t, test.res, estimate, 1e-1, fmt.Sprintf("%s estimate: %.2f expected: %.2f", test.loss.String(), estimate, test.res))
 		assert.NotEqual(t, "N/A", test.loss.String())
 	}
 }
+
+func Test_WeightedLoss(t *testing.T) {
+	estimate := [][]float64{{1, 2}, {3, 4}}
+	ideal := [][]float64{{0, 0}, {0, 0}}
+
+	// (1·1² + 3·3² + 3·4²) / (1 + 3 + 3)
+	mse := GetLoss(LossMeanSquared).F(estimate, ideal, []float64{1, 3}, [][]bool{{true, false}, nil})
+	assert.InEpsilon(t, 76.0/7, mse, 1e-12)
+
+	estimate = [][]float64{{0.9}, {0.1}}
+	ideal = [][]float64{{1}, {1}}
+	unweighted := GetLoss(LossBinaryCrossEntropy).F(estimate[:1], ideal[:1], nil, nil)
+	assert.InEpsilon(t, unweighted, GetLoss(LossBinaryCrossEntropy).F(estimate, ideal, []float64{1, 0}, nil), 1e-12)
+	assert.InEpsilon(t, unweighted, GetLoss(LossBinaryCrossEntropy).F(estimate, ideal, nil, [][]bool{nil, {false}})*2, 1e-12)
+}
+
+// checkBatchGradient compares BatchDf with central differences of F, where
+// scale turns the average returned by F into the sum differentiated by BatchDf
+func checkBatchGradient(t *testing.T, loss BatchLoss, estimate, ideal [][]float64, weights []float64, mask [][]bool, scale float64) {
+	grad := loss.BatchDf(estimate, ideal, weights, mask)
+
+	const h = 1e-6
+	for i := range estimate {
+		for j := range estimate[i] {
+			estimate[i][j] += h
+			up := loss.F(estimate, ideal, weights, mask)
+			estimate[i][j] -= 2 * h
+			down := loss.F(estimate, ideal, weights, mask)
+			estimate[i][j] += h
+
+			numeric := scale * (up - down) / (2 * h)
+			assert.True(t, math.Abs(numeric-grad[i][j]) < 1e-6, fmt.Sprintf("%d,%d: numeric %f analytic %f", i, j, numeric, grad[i][j]))
+		}
+	}
+}
+
+func Test_CoxGradient(t *testing.T) {
+	estimate := [][]float64{{0.3}, {-0.2}, {0.5}, {0.1}, {-0.4}}
+	ideal := [][]float64{{2, 1}, {1, 0}, {2, 1}, {4, 1}, {3, 0}}
+
+	// 3 observed events
+	checkBatchGradient(t, Cox{}, estimate, ideal, nil, nil, 3)
+	// Observed events weighing 0.5 and 2, as the third is masked
+	checkBatchGradient(t, Cox{}, estimate, ideal,
+		[]float64{0.5, 1, 1, 2, 3},
+		[][]bool{nil, nil, {true, false}, nil, nil}, 2.5)
+}
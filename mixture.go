@@ -0,0 +1,105 @@
+package deep
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Mixture is a mixture of Gaussians with diagonal covariances
+type Mixture struct {
+	// Mixing weights of the components, summing to 1
+	Weights []float64
+	// Means of every component
+	Means [][]float64
+	// Variances of every component, per dimension
+	Variances [][]float64
+}
+
+// MixtureOutputs returns the number of outputs of a mixture density
+// network modelling k components over dim-dimensional targets
+func MixtureOutputs(k, dim int) int {
+	return k * (2*dim + 1)
+}
+
+// NewMixture parameterizes a mixture of k components from raw network outputs,
+// laid out as k mixing logits followed by k·dim means and k·dim log-variances
+func NewMixture(out []float64, k int) *Mixture {
+	dim := (len(out)/k - 1) / 2
+	m := &Mixture{
+		Weights:   Softmax(out[:k]),
+		Means:     make([][]float64, k),
+		Variances: make([][]float64, k),
+	}
+	for i := 0; i < k; i++ {
+		m.Means[i] = make([]float64, dim)
+		m.Variances[i] = make([]float64, dim)
+		for d := 0; d < dim; d++ {
+			m.Means[i][d] = out[k+i*dim+d]
+			m.Variances[i][d] = math.Exp(out[k+k*dim+i*dim+d])
+		}
+	}
+	return m
+}
+
+// Mean is the expectation of the mixture
+func (m *Mixture) Mean() []float64 {
+	mean := make([]float64, len(m.Means[0]))
+	for i, w := range m.Weights {
+		for d, mu := range m.Means[i] {
+			mean[d] += w * mu
+		}
+	}
+	return mean
+}
+
+// LogLikelihood is the log density of the mixture at x
+func (m *Mixture) LogLikelihood(x []float64) float64 {
+	logs := make([]float64, len(m.Weights))
+	for i, w := range m.Weights {
+		logs[i] = math.Log(w)
+		for d := range x {
+			logs[i] += logNormal(x[d], m.Means[i][d], math.Log(m.Variances[i][d]))
+		}
+	}
+	return logSumExp(logs)
+}
+
+// Sample draws a value from the mixture using r
+func (m *Mixture) Sample(r *rand.Rand) []float64 {
+	i, u := 0, r.Float64()
+	for ; i < len(m.Weights)-1; i++ {
+		if u -= m.Weights[i]; u < 0 {
+			break
+		}
+	}
+	x := make([]float64, len(m.Means[i]))
+	for d := range x {
+		x[d] = normal(r, math.Sqrt(m.Variances[i][d]), m.Means[i][d])
+	}
+	return x
+}
+
+// PredictDistribution computes a forward pass of a mixture density network
+// and returns the predicted distribution
+func (n *Neural) PredictDistribution(input []float64) *Mixture {
+	return NewMixture(n.Predict(input), n.Config.Components)
+}
+
+// Sample draws a value from the distribution predicted for input
+func (n *Neural) Sample(input []float64) []float64 {
+	return n.PredictDistribution(input).Sample(n.random())
+}
+
+// logNormal is the log density of N(mean, exp(logVar)) at x
+func logNormal(x, mean, logVar float64) float64 {
+	return -0.5 * (math.Log(2*math.Pi) + logVar + (x-mean)*(x-mean)*math.Exp(-logVar))
+}
+
+func logSumExp(xx []float64) float64 {
+	max := Max(xx)
+	var sum float64
+	for _, x := range xx {
+		sum += math.Exp(x - max)
+	}
+	return max + math.Log(sum)
+}
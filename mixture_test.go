@@ -0,0 +1,62 @@
+package deep
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewMixture(t *testing.T) {
+	m := NewMixture([]float64{0, 0, 1, -1, 0, math.Log(4)}, 2)
+
+	assert.Equal(t, []float64{0.5, 0.5}, m.Weights)
+	assert.Equal(t, [][]float64{{1}, {-1}}, m.Means)
+	assert.Equal(t, [][]float64{{1}, {4}}, m.Variances)
+	assert.Equal(t, []float64{0}, m.Mean())
+	assert.Equal(t, 6, MixtureOutputs(2, 1))
+
+	// Equal mixture of N(1, 1) and N(-1, 2²) at 1
+	p := 0.5/math.Sqrt(2*math.Pi) + 0.5*math.Exp(-0.5)/(2*math.Sqrt(2*math.Pi))
+	assert.InEpsilon(t, math.Log(p), m.LogLikelihood([]float64{1}), 1e-12)
+}
+
+func Test_MixtureSample(t *testing.T) {
+	rand.Seed(0)
+	m := NewMixture([]float64{10, -10, 5, -5, -4, -4}, 2)
+
+	for i := 0; i < 100; i++ {
+		assert.InDelta(t, 5, m.Sample(globalRand)[0], 1)
+	}
+}
+
+func Test_MixtureDensityGradient(t *testing.T) {
+	estimate := [][]float64{
+		{0.2, -0.3, 0.5, -1, 0.4, 1, -0.2, 0.3, 0.1, -0.5},
+		{-0.1, 0.6, 0.2, 0.3, -0.7, 0.1, 0.4, -0.3, 0.2, 0.8},
+	}
+	ideal := [][]float64{{0.1, -0.4}, {1.2, 0.3}}
+
+	checkBatchGradient(t, MixtureDensity{}, estimate, ideal, nil, nil, 2)
+	checkBatchGradient(t, MixtureDensity{}, estimate, ideal, []float64{0.5, 2}, [][]bool{{false, true}, nil}, 2.5)
+}
+
+func Test_MixtureDensityLayout(t *testing.T) {
+	c := &Config{Inputs: 1, Layout: []int{4, MixtureOutputs(2, 1)}, Mode: ModeMixtureDensity, Components: 2}
+	assert.NoError(t, c.Validate())
+	c.Layout = []int{4, MixtureOutputs(2, 2)}
+	assert.NoError(t, c.Validate())
+
+	// Outputs which are not those of whole components are rejected
+	for _, outputs := range []int{5, 7, 2, 8} {
+		c.Layout = []int{4, outputs}
+		assert.Error(t, c.Validate(), "%d outputs", outputs)
+	}
+	_, err := New(c)
+	assert.Error(t, err)
+
+	// and so are targets of another dimension
+	estimate := [][]float64{make([]float64, MixtureOutputs(2, 1))}
+	assert.Panics(t, func() { MixtureDensity{}.F(estimate, [][]float64{{1, 2}}, nil, nil) })
+}
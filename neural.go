@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 )
 
@@ -13,6 +14,8 @@ type Neural struct {
 	Layers []*Layer
 	Biases [][]*Synapse
 	Config *Config
+
+	rng *rand.Rand
 }
 
 // Config defines the network topology, activations, losses etc
@@ -26,23 +29,78 @@ type Config struct {
 	Layout []int
 	// Activation functions: {ActivationTanh, ActivationReLU, ActivationSigmoid}
 	Activation ActivationType
-	// Solver modes: {ModeRegression, ModeBinary, ModeMultiClass, ModeMultiLabel}
+	// Per-layer activations overriding Activation and the output activation
+	// of Mode, where ActivationNone entries keep the default
+	Activations []ActivationType `json:",omitempty"`
+	// Solver modes: {ModeRegression, ModeBinary, ModeMultiClass, ModeMultiLabel, ModeSurvival, ModeMixtureDensity}
 	Mode Mode
-	// Initializer for weights: {NewNormal(σ, μ), NewUniform(σ, μ)}
+	// Number of Gaussian components in ModeMixtureDensity, where the output
+	// layer must contain MixtureOutputs(Components, dimension of targets) nodes
+	Components int
+	// Serializable initializer for weights, defaults to {"kind":"uniform","std":0.5}
+	Init *Initializer
+	// Custom initializer for weights, which takes precedence over Init but is not serialized:
+	// {NewNormal(σ, μ), NewUniform(σ, μ), NewGlorotUniform(), NewGlorotNormal(), NewHe(), NewLeCun()}
 	Weight WeightInitializer `json:"-"`
-	// Loss functions: {LossCrossEntropy, LossBinaryCrossEntropy, LossMeanSquared}
+	// Loss functions: {LossCrossEntropy, LossBinaryCrossEntropy, LossMeanSquared, LossCox, LossMixtureDensity}
 	Loss LossType
 	// Apply bias nodes
 	Bias bool
 	// Error/Loss precision
 	LossPrecision int
+	// Seed of the random numbers drawn for initial weights and samples,
+	// which are drawn from the global math/rand source when zero
+	Seed int64
+}
+
+// New returns a new neural network, or an error if c is invalid
+func New(c *Config) (*Neural, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return NewNeural(c), nil
+}
+
+// Validate returns an error if NewNeural can't build a network from c, which
+// is when Init is of an unknown kind without a custom Weight to override it,
+// or when the outputs of a mixture density network are not the parameters of
+// Components whole components
+func (c *Config) Validate() error {
+	if c.Weight == nil && c.Init != nil {
+		if _, err := c.Init.WeightInitializer(); err != nil {
+			return err
+		}
+	}
+	mixture := c.Loss == LossMixtureDensity || c.Loss == LossNone && c.Mode == ModeMixtureDensity
+	if mixture && len(c.Layout) > 0 {
+		k := c.Components
+		if k == 0 {
+			k = 1
+		}
+		// Every component has a logit, and a mean and log-variance per dimension
+		outputs := c.Layout[len(c.Layout)-1]
+		if outputs%k != 0 || outputs/k < 3 || (outputs/k-1)%2 != 0 {
+			return fmt.Errorf("Invalid mixture density outputs %d - expected MixtureOutputs(%d, dimension)", outputs, k)
+		}
+	}
+	return nil
 }
 
-// NewNeural returns a new neural network
+// NewNeural returns a new neural network. It panics if c is invalid, which
+// New reports as an error instead.
 func NewNeural(c *Config) *Neural {
+	if err := c.Validate(); err != nil {
+		panic(err)
+	}
 
-	if c.Weight == nil {
-		c.Weight = NewUniform(0.5, 0)
+	// A custom initializer takes precedence over Init, which describes the
+	// default one otherwise
+	weight := c.Weight
+	if weight == nil {
+		if c.Init == nil {
+			c.Init = &Initializer{Kind: "uniform", Std: 0.5}
+		}
+		weight, _ = c.Init.WeightInitializer()
 	}
 	if c.Activation == ActivationNone {
 		c.Activation = ActivationSigmoid
@@ -53,58 +111,90 @@ func NewNeural(c *Config) *Neural {
 			c.Loss = LossCrossEntropy
 		case ModeBinary:
 			c.Loss = LossBinaryCrossEntropy
+		case ModeSurvival:
+			c.Loss = LossCox
+		case ModeMixtureDensity:
+			c.Loss = LossMixtureDensity
 		default:
 			c.Loss = LossMeanSquared
 		}
 	}
+	if c.Mode == ModeMixtureDensity && c.Components == 0 {
+		c.Components = 1
+	}
 	if c.LossPrecision == 0 {
 		c.LossPrecision = 4
 	}
 
-	layers := initializeLayers(c)
+	rng := NewRand(c.Seed)
+	layers := initializeLayers(c, rng, weight)
 
 	var biases [][]*Synapse
 	if c.Bias {
 		biases = make([][]*Synapse, len(layers))
 		for i := 0; i < len(layers); i++ {
-			if c.Mode == ModeRegression && i == len(layers)-1 {
+			if (c.Mode == ModeRegression || c.Mode == ModeSurvival) && i == len(layers)-1 {
 				continue
 			}
-			biases[i] = layers[i].ApplyBias(c.Weight)
+			fanIn := c.Inputs
+			if i > 0 {
+				fanIn = c.Layout[i-1]
+			}
+			biases[i] = layers[i].ApplyBias(fanIn, rng, weight)
 		}
 	}
 
-	return &Neural{
+	n := &Neural{
 		Layers: layers,
 		Biases: biases,
 		Config: c,
+		rng:    rng,
 	}
+	if c.Weight == nil {
+		c.Init.apply(n)
+	}
+	return n
 }
 
-func initializeLayers(c *Config) []*Layer {
+func initializeLayers(c *Config, rng *rand.Rand, weight WeightInitializer) []*Layer {
 	layers := make([]*Layer, len(c.Layout))
 	for i := range layers {
-		act := c.Activation
-		if i == (len(layers)-1) && c.Mode != ModeDefault {
-			act = OutputActivation(c.Mode)
-		}
-		layers[i] = NewLayer(c.Layout[i], act)
+		layers[i] = NewLayer(c.Layout[i], c.LayerActivation(i))
 	}
 
 	for _, neuron := range layers[0].Neurons {
 		neuron.In = make([]*Synapse, c.Inputs)
 		for i := range neuron.In {
-			neuron.In[i] = NewSynapse(c.Weight())
+			neuron.In[i] = NewSynapse(weight(rng, c.Inputs, c.Layout[0]))
 		}
 	}
 
 	for i := 0; i < len(layers)-1; i++ {
-		layers[i].Connect(layers[i+1], c.Weight)
+		layers[i].Connect(layers[i+1], rng, weight)
 	}
 
 	return layers
 }
 
+// LayerActivation returns the activation of layer i
+func (c *Config) LayerActivation(i int) ActivationType {
+	if i < len(c.Activations) && c.Activations[i] != ActivationNone {
+		return c.Activations[i]
+	}
+	if i == len(c.Layout)-1 && c.Mode != ModeDefault {
+		return OutputActivation(c.Mode)
+	}
+	return c.Activation
+}
+
+// random returns the random number generator of n
+func (n *Neural) random() *rand.Rand {
+	if n.rng == nil {
+		return globalRand
+	}
+	return n.rng
+}
+
 func (n *Neural) fire() {
 	for _, b := range n.Biases {
 		for _, s := range b {
@@ -130,6 +220,29 @@ func (n *Neural) Forward(input []float64) error {
 	return nil
 }
 
+// RecurrentState returns the outputs of the recurrent synapses, which carry
+// the values of the hidden neurons over to the next forward pass
+func (n *Neural) RecurrentState() []float64 {
+	var state []float64
+	for _, l := range n.Layers[:len(n.Layers)-1] {
+		for _, neuron := range l.Neurons {
+			state = append(state, neuron.Out[len(neuron.Out)-1].Out)
+		}
+	}
+	return state
+}
+
+// SetRecurrentState restores a state returned by RecurrentState, so that the
+// next forward pass computes as it would have then
+func (n *Neural) SetRecurrentState(state []float64) {
+	for _, l := range n.Layers[:len(n.Layers)-1] {
+		for _, neuron := range l.Neurons {
+			neuron.Out[len(neuron.Out)-1].Out = state[0]
+			state = state[1:]
+		}
+	}
+}
+
 // Predict computes a forward pass and returns a prediction
 func (n *Neural) Predict(input []float64) []float64 {
 	n.Forward(input)
@@ -0,0 +1,220 @@
+package deep
+
+import (
+	"fmt"
+	"math"
+)
+
+// Neural32 is a single precision neural network with the same topology as a
+// Neural, storing the weights feeding every neuron contiguously rather than
+// as a graph of synapses, which halves its memory footprint
+type Neural32 struct {
+	Config *Config
+	// Weights feeding every neuron, laid out like Neural.Weights
+	Weights [][][]float32
+	// Pruned weights, laid out like Weights, if any
+	Pruned [][][]bool
+
+	input  []float32
+	values [][]float32
+	// Outputs of the recurrent synapses, which like those of a Neural are
+	// weighted when firing and feed the next forward pass
+	recurrent [][]float32
+}
+
+// NewNeural32 returns a new single precision neural network
+func NewNeural32(c *Config) *Neural32 {
+	return NewNeural(c).Float32()
+}
+
+// Float32 converts n to single precision
+func (n *Neural) Float32() *Neural32 {
+	return Neural32FromDump(n.Dump())
+}
+
+// Neural32FromDump restores a single precision network from a dump
+func Neural32FromDump(dump *Dump) *Neural32 {
+	n := &Neural32{
+		Config:    dump.Config,
+		Weights:   make([][][]float32, len(dump.Weights)),
+		Pruned:    dump.Pruned,
+		values:    make([][]float32, len(dump.Weights)),
+		recurrent: make([][]float32, len(dump.Weights)),
+	}
+	for i, l := range dump.Weights {
+		n.Weights[i] = make([][]float32, len(l))
+		for j, ws := range l {
+			n.Weights[i][j] = make([]float32, len(ws))
+			for k, w := range ws {
+				n.Weights[i][j][k] = float32(w)
+			}
+		}
+		n.values[i] = make([]float32, len(l))
+		n.recurrent[i] = make([]float32, len(l))
+	}
+	return n
+}
+
+// Dump generates a (double precision) network dump
+func (n *Neural32) Dump() *Dump {
+	weights := make([][][]float64, len(n.Weights))
+	for i, l := range n.Weights {
+		weights[i] = make([][]float64, len(l))
+		for j, ws := range l {
+			weights[i][j] = make([]float64, len(ws))
+			for k, w := range ws {
+				weights[i][j][k] = float64(w)
+			}
+		}
+	}
+	return &Dump{
+		Config:  n.Config,
+		Weights: weights,
+		Pruned:  n.Pruned,
+	}
+}
+
+// Float64 converts n to double precision
+func (n *Neural32) Float64() *Neural {
+	return FromDump(n.Dump())
+}
+
+// fanIn is the number of neurons, or inputs, feeding layer i
+func (n *Neural32) fanIn(i int) int {
+	if i == 0 {
+		return n.Config.Inputs
+	}
+	return len(n.Weights[i-1])
+}
+
+// In is the value last fed into synapse k of neuron j in layer i, as
+// in Synapse.In. For recurrent synapses, this is the neuron's own value.
+func (n *Neural32) In(i, j, k int) float32 {
+	switch fanIn := n.fanIn(i); {
+	case k < fanIn && i == 0:
+		return n.input[k]
+	case k < fanIn:
+		return n.values[i-1][k]
+	case k == fanIn && n.isRecurrent(i):
+		return n.values[i][j]
+	}
+	return 1
+}
+
+// isRecurrent reports whether the neurons of layer i have recurrent synapses
+func (n *Neural32) isRecurrent(i int) bool {
+	return i < len(n.Weights)-1
+}
+
+// Forward computes a forward pass
+func (n *Neural32) Forward(input []float32) error {
+	if len(input) != n.Config.Inputs {
+		return fmt.Errorf("Invalid input dimension - expected: %d got: %d", n.Config.Inputs, len(input))
+	}
+	n.input = input
+
+	for i, l := range n.Weights {
+		act := GetActivation(n.layerActivation(i))
+		fanIn := n.fanIn(i)
+		for j, ws := range l {
+			var sum float32
+			for k, w := range ws {
+				out := w * n.In(i, j, k)
+				if k == fanIn && n.isRecurrent(i) {
+					out = n.recurrent[i][j]
+				}
+				if x := sum + out; !isNaN32(x) {
+					sum = x
+				}
+			}
+			n.values[i][j] = float32(act.F(float64(sum)))
+			if n.isRecurrent(i) {
+				n.recurrent[i][j] = n.values[i][j] * ws[fanIn]
+			}
+		}
+		if n.layerActivation(i) == ActivationSoftmax {
+			n.softmax(i)
+		}
+	}
+	return nil
+}
+
+func (n *Neural32) softmax(i int) {
+	outs := make([]float64, len(n.values[i]))
+	for j, v := range n.values[i] {
+		outs[j] = float64(v)
+	}
+	for j, v := range Softmax(outs) {
+		n.values[i][j] = float32(v)
+	}
+}
+
+// Predict computes a forward pass and returns a prediction
+func (n *Neural32) Predict(input []float32) []float32 {
+	n.Forward(input)
+
+	out := make([]float32, len(n.values[len(n.values)-1]))
+	copy(out, n.values[len(n.values)-1])
+	return out
+}
+
+// Backward backpropagates the deltas of the output layer, the derivatives of
+// the loss with respect to the input of each output neuron, through the last
+// forward pass and accumulates the derivative with respect to every weight
+// into grad, which is laid out like Weights. It repeats the chain rule of
+// the double precision training, which walks synapses rather than these
+// contiguous weights, since sharing it would mean widening every value to
+// float64 and back, defeating single precision.
+func (n *Neural32) Backward(deltas []float32, grad [][][]float32) {
+	for i := len(n.Weights) - 1; i >= 0; i-- {
+		for j, ws := range n.Weights[i] {
+			for k := range ws {
+				grad[i][j][k] += deltas[j] * n.In(i, j, k)
+			}
+		}
+		if i == 0 {
+			break
+		}
+
+		prev := make([]float32, len(n.Weights[i-1]))
+		act := GetActivation(n.layerActivation(i - 1))
+		for k := range prev {
+			var sum float32
+			for j, ws := range n.Weights[i] {
+				sum += ws[k] * deltas[j]
+			}
+			if d := float32(act.Df(float64(n.values[i-1][k]))) * sum; !isNaN32(d) {
+				prev[k] = d
+			}
+		}
+		deltas = prev
+	}
+}
+
+// Outputs returns the values of the output layer of the last forward pass
+func (n *Neural32) Outputs() []float32 {
+	return n.values[len(n.values)-1]
+}
+
+// OutputActivation is the activation of the output layer
+func (n *Neural32) OutputActivation() Differentiable {
+	return GetActivation(n.layerActivation(len(n.Weights) - 1))
+}
+
+func (n *Neural32) layerActivation(i int) ActivationType {
+	return n.Config.LayerActivation(i)
+}
+
+// NumWeights returns the number of weights in the network
+func (n *Neural32) NumWeights() (num int) {
+	for _, l := range n.Weights {
+		for _, ws := range l {
+			num += len(ws)
+		}
+	}
+	return
+}
+
+func isNaN32(x float32) bool {
+	return math.IsNaN(float64(x))
+}
@@ -0,0 +1,101 @@
+package deep
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Neural32Parity(t *testing.T) {
+	rand.Seed(0)
+
+	for _, mode := range []Mode{ModeMultiClass, ModeRegression, ModeBinary} {
+		n := NewNeural(&Config{
+			Inputs:     3,
+			Layout:     []int{5, 4, 3},
+			Activation: ActivationTanh,
+			Mode:       mode,
+			Bias:       true,
+		})
+		n32 := n.Float32()
+		assert.Equal(t, n.NumWeights(), n32.NumWeights())
+
+		// Recurrent state carries over between consecutive passes
+		for i := 0; i < 5; i++ {
+			x := []float64{rand.Float64(), rand.Float64(), rand.Float64()}
+			y := n.Predict(x)
+			y32 := n32.Predict([]float32{float32(x[0]), float32(x[1]), float32(x[2])})
+			for j := range y {
+				assert.InDelta(t, y[j], float64(y32[j]), 1e-5)
+			}
+		}
+	}
+}
+
+func Test_Neural32Dump(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural32(&Config{
+		Inputs: 2,
+		Layout: []int{3, 1},
+		Bias:   true,
+	})
+
+	restored := Neural32FromDump(n.Float64().Dump())
+	assert.Equal(t, n.Weights, restored.Weights)
+
+	assert.Error(t, n.Forward([]float32{1}))
+}
+
+func Test_Neural32Backward(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural32(&Config{
+		Inputs:     2,
+		Layout:     []int{3, 2},
+		Activation: ActivationSigmoid,
+		Mode:       ModeRegression,
+		Bias:       true,
+	})
+	x := []float32{0.3, -0.6}
+
+	// Gradient of the sum of outputs, whose activation is linear
+	grad := make([][][]float32, len(n.Weights))
+	for i, l := range n.Weights {
+		grad[i] = make([][]float32, len(l))
+		for j, ws := range l {
+			grad[i][j] = make([]float32, len(ws))
+		}
+	}
+	n.Forward([]float32{0.9, 0.1})
+	state := append([]float32{}, n.recurrent[0]...)
+	n.Forward(x)
+	n.Backward([]float32{1, 1}, grad)
+
+	const h = 1e-2
+	for i, l := range n.Weights {
+		for j, ws := range l {
+			for k := range ws {
+				if k == n.fanIn(i) && n.isRecurrent(i) {
+					// Recurrent synapses only affect the following pass
+					continue
+				}
+				ws[k] += h
+				copy(n.recurrent[0], state)
+				up := sum32(n.Predict(x))
+				ws[k] -= 2 * h
+				copy(n.recurrent[0], state)
+				down := sum32(n.Predict(x))
+				ws[k] += h
+
+				assert.InDelta(t, (up-down)/(2*h), grad[i][j][k], 1e-3)
+			}
+		}
+	}
+}
+
+func sum32(xx []float32) (sum float32) {
+	for _, x := range xx {
+		sum += x
+	}
+	return
+}
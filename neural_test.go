@@ -108,3 +108,11 @@ func Test_NumWeights(t *testing.T) {
 	n := NewNeural(&Config{Layout: []int{5, 5, 3}})
 	assert.Equal(t, 5 + 5*6 + 3*5, n.NumWeights())
 }
+
+func Test_Seed(t *testing.T) {
+	c := func(seed int64) *Config {
+		return &Config{Inputs: 3, Layout: []int{4, 2}, Bias: true, Seed: seed, Init: &Initializer{Kind: "orthogonal"}}
+	}
+	assert.Equal(t, NewNeural(c(1)).Weights(), NewNeural(c(1)).Weights())
+	assert.NotEqual(t, NewNeural(c(1)).Weights(), NewNeural(c(2)).Weights())
+}
@@ -2,15 +2,18 @@ package deep
 
 import (
 	"math"
+	"sync/atomic"
 )
 
-
 // Neuron is a neural network node
 type Neuron struct {
 	A     ActivationType
 	In    []*Synapse
 	Out   []*Synapse
 	Value float64
+
+	// delta is the gradient with respect to the input of the activation
+	delta float64
 }
 
 // NewNeuron returns a neuron with the given activation
@@ -23,7 +26,7 @@ func NewNeuron(activation ActivationType) *Neuron {
 func (n *Neuron) fire() {
 	var sum float64
 	for _, s := range n.In {
-		if !math.IsNaN(sum + s.Out) {
+		if !s.Pruned && !math.IsNaN(sum+s.Out) {
 			sum += s.Out
 		}
 	}
@@ -31,7 +34,9 @@ func (n *Neuron) fire() {
 
 	nVal := n.Value
 	for _, s := range n.Out {
-		s.fire(nVal)
+		if !s.Pruned {
+			s.fire(nVal)
+		}
 	}
 }
 
@@ -50,6 +55,14 @@ type Synapse struct {
 	Weight  float64
 	In, Out float64
 	IsBias  bool
+	// Pruned synapses have a zero weight and are skipped
+	Pruned bool `json:",omitempty"`
+	// Gradient accumulated by Neural.Backward
+	Gradient float64 `json:"-"`
+
+	// shared is the weight the synapse reads in place of Weight, when its
+	// network shares weights
+	shared *uint64
 }
 
 // NewSynapse returns a synapse with the specified initialized weight
@@ -57,7 +70,22 @@ func NewSynapse(weight float64) *Synapse {
 	return &Synapse{Weight: weight}
 }
 
+// Prune removes the synapse from the network by fixing its weight at zero
+func (s *Synapse) Prune() {
+	s.Weight, s.In, s.Out = 0, 0, 0
+	s.Pruned = true
+}
+
 func (s *Synapse) fire(value float64) {
 	s.In = value
-	s.Out = s.In * s.Weight
+	s.Out = s.In * s.weight()
+}
+
+// weight is the weight of the synapse, read from the shared weights of its
+// network if any
+func (s *Synapse) weight() float64 {
+	if s.shared != nil {
+		return math.Float64frombits(atomic.LoadUint64(s.shared))
+	}
+	return s.Weight
 }
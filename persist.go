@@ -2,12 +2,15 @@ package deep
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 // Dump is a neural network dump
 type Dump struct {
 	Config  *Config
 	Weights [][][]float64
+	// Pruned weights, laid out like Weights, if any
+	Pruned [][][]bool `json:",omitempty"`
 }
 
 // ApplyWeights sets the weights from a three-dimensional slice
@@ -38,16 +41,23 @@ func (n Neural) Weights() [][][]float64 {
 
 // Dump generates a network dump
 func (n Neural) Dump() *Dump {
-	return &Dump{
+	dump := &Dump{
 		Config:  n.Config,
 		Weights: n.Weights(),
 	}
+	if n.Sparsity() > 0 {
+		dump.Pruned = n.Masks()
+	}
+	return dump
 }
 
 // FromDump restores a Neural from a dump
 func FromDump(dump *Dump) *Neural {
 	n := NewNeural(dump.Config)
 	n.ApplyWeights(dump.Weights)
+	if dump.Pruned != nil {
+		n.ApplyMasks(dump.Pruned)
+	}
 
 	return n
 }
@@ -63,5 +73,11 @@ func Unmarshal(bytes []byte) (*Neural, error) {
 	if err := json.Unmarshal(bytes, &dump); err != nil {
 		return nil, err
 	}
+	if dump.Config == nil {
+		return nil, fmt.Errorf("Missing config")
+	}
+	if err := dump.Config.Validate(); err != nil {
+		return nil, err
+	}
 	return FromDump(&dump), nil
 }
@@ -0,0 +1,206 @@
+package deep
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PruneGlobal prunes the smallest-magnitude weights of n, ranked across all
+// layers, until the given fraction of its non-bias weights is pruned
+func PruneGlobal(n *Neural, fraction float64) {
+	var synapses []*Synapse
+	for _, l := range n.Layers {
+		synapses = append(synapses, prunable(l)...)
+	}
+	pruneSmallest(synapses, fraction)
+}
+
+// PruneLayers prunes the smallest-magnitude weights of every layer of n,
+// until the given fraction of the non-bias weights of each is pruned
+func PruneLayers(n *Neural, fraction float64) {
+	for _, l := range n.Layers {
+		pruneSmallest(prunable(l), fraction)
+	}
+}
+
+// prunable returns the synapses feeding l which may be pruned
+func prunable(l *Layer) (synapses []*Synapse) {
+	for _, neuron := range l.Neurons {
+		for _, s := range neuron.In {
+			if !s.IsBias {
+				synapses = append(synapses, s)
+			}
+		}
+	}
+	return
+}
+
+func pruneSmallest(synapses []*Synapse, fraction float64) {
+	sort.SliceStable(synapses, func(i, j int) bool {
+		if synapses[i].Pruned != synapses[j].Pruned {
+			return synapses[i].Pruned
+		}
+		return math.Abs(synapses[i].Weight) < math.Abs(synapses[j].Weight)
+	})
+	for _, s := range synapses[:int(math.Round(fraction*float64(len(synapses))))] {
+		s.Prune()
+	}
+}
+
+// Sparsity is the fraction of non-bias weights of n that are pruned
+func (n *Neural) Sparsity() float64 {
+	var pruned, total int
+	for _, l := range n.Layers {
+		for _, s := range prunable(l) {
+			if s.Pruned {
+				pruned++
+			}
+			total++
+		}
+	}
+	return float64(pruned) / float64(total)
+}
+
+// PruneNeurons removes the count neurons of hidden layer i whose outgoing
+// weights have the smallest norm, along with all of their synapses
+func PruneNeurons(n *Neural, i, count int) error {
+	remove, err := weakestNeurons(n, i, count)
+	if err != nil {
+		return err
+	}
+	// Remove from the highest index down, so that lower indices stay valid
+	sort.Sort(sort.Reverse(sort.IntSlice(remove)))
+	for _, j := range remove {
+		RemoveNeuron(n, i, j)
+	}
+	return nil
+}
+
+// MaskNeurons prunes every synapse of the count neurons of hidden layer i
+// whose outgoing weights have the smallest norm, leaving the topology of n
+// unchanged so that it can go on training. Neurons masked before have no
+// outgoing weights, and so count towards count. RemoveMaskedNeurons then
+// removes them.
+func MaskNeurons(n *Neural, i, count int) error {
+	mask, err := weakestNeurons(n, i, count)
+	if err != nil {
+		return err
+	}
+	next := len(n.Layers[i+1].Neurons)
+	for _, j := range mask {
+		neuron := n.Layers[i].Neurons[j]
+		for _, s := range neuron.In {
+			s.Prune()
+		}
+		for _, s := range neuron.Out[:next] {
+			s.Prune()
+		}
+	}
+	return nil
+}
+
+// RemoveMaskedNeurons removes the neurons of the hidden layers of n whose
+// outgoing synapses are all pruned, which have no effect on its outputs
+func RemoveMaskedNeurons(n *Neural) {
+	for i := len(n.Layers) - 2; i >= 0; i-- {
+		next := len(n.Layers[i+1].Neurons)
+		for j := len(n.Layers[i].Neurons) - 1; j >= 0; j-- {
+			masked := true
+			for _, s := range n.Layers[i].Neurons[j].Out[:next] {
+				masked = masked && s.Pruned
+			}
+			if masked && len(n.Layers[i].Neurons) > 1 {
+				RemoveNeuron(n, i, j)
+			}
+		}
+	}
+}
+
+// weakestNeurons returns the count neurons of hidden layer i of n whose
+// outgoing weights have the smallest norm
+func weakestNeurons(n *Neural, i, count int) ([]int, error) {
+	if i >= len(n.Layers)-1 {
+		return nil, fmt.Errorf("Invalid layer %d - only hidden layers can be pruned", i)
+	}
+	if count >= len(n.Layers[i].Neurons) {
+		return nil, fmt.Errorf("Cannot remove %d of %d neurons", count, len(n.Layers[i].Neurons))
+	}
+
+	norms := make([]float64, len(n.Layers[i].Neurons))
+	order := make([]int, len(norms))
+	for j, neuron := range n.Layers[i].Neurons {
+		for _, s := range neuron.Out[:len(n.Layers[i+1].Neurons)] {
+			norms[j] += s.Weight * s.Weight
+		}
+		order[j] = j
+	}
+	sort.SliceStable(order, func(a, b int) bool { return norms[order[a]] < norms[order[b]] })
+	return order[:count], nil
+}
+
+// RemoveNeuron removes neuron j from hidden layer i of n, along with its
+// incoming, outgoing, recurrent and bias synapses
+func RemoveNeuron(n *Neural, i, j int) {
+	if i > 0 {
+		for _, prev := range n.Layers[i-1].Neurons {
+			prev.Out = append(prev.Out[:j:j], prev.Out[j+1:]...)
+		}
+	}
+	for _, next := range n.Layers[i+1].Neurons {
+		next.In = append(next.In[:j:j], next.In[j+1:]...)
+	}
+	if n.Biases != nil && n.Biases[i] != nil {
+		n.Biases[i] = append(n.Biases[i][:j:j], n.Biases[i][j+1:]...)
+	}
+	l := n.Layers[i]
+	l.Neurons = append(l.Neurons[:j:j], l.Neurons[j+1:]...)
+
+	// The configuration may be shared with other networks
+	c := *n.Config
+	c.Layout = append([]int{}, c.Layout...)
+	c.Layout[i] = len(l.Neurons)
+	n.Config = &c
+}
+
+// Rewind resets the weights of n which are not pruned to the given weights,
+// such as those it was initialized with, for lottery ticket style retraining
+func (n *Neural) Rewind(weights [][][]float64) {
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if !s.Pruned {
+					s.Weight = weights[i][j][k]
+				}
+			}
+		}
+	}
+}
+
+// Masks returns whether each weight of n is pruned, laid out like Weights
+func (n Neural) Masks() [][][]bool {
+	masks := make([][][]bool, len(n.Layers))
+	for i, l := range n.Layers {
+		masks[i] = make([][]bool, len(l.Neurons))
+		for j, neuron := range l.Neurons {
+			masks[i][j] = make([]bool, len(neuron.In))
+			for k, s := range neuron.In {
+				masks[i][j][k] = s.Pruned
+			}
+		}
+	}
+	return masks
+}
+
+// ApplyMasks prunes the weights of n marked in masks, laid out like Weights
+func (n *Neural) ApplyMasks(masks [][][]bool) {
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if masks[i][j][k] {
+					s.Prune()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package deep
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Prune(t *testing.T) {
+	c := &Config{
+		Inputs:     4,
+		Layout:     []int{8, 6, 2},
+		Activation: ActivationTanh,
+		Bias:       true,
+		Seed:       1,
+	}
+
+	n := NewNeural(c)
+	PruneLayers(n, 0.5)
+	for _, l := range n.Layers {
+		var pruned int
+		for _, s := range prunable(l) {
+			if s.Pruned {
+				pruned++
+				assert.Equal(t, 0.0, s.Weight)
+			}
+		}
+		assert.Equal(t, len(prunable(l))/2, pruned)
+	}
+
+	n = NewNeural(c)
+	PruneGlobal(n, 0.75)
+	assert.InDelta(t, 0.75, n.Sparsity(), 0.01)
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if s.IsBias {
+					assert.False(t, s.Pruned)
+				}
+			}
+		}
+	}
+
+	// Pruning further keeps previously pruned weights
+	masks := n.Masks()
+	PruneGlobal(n, 0.9)
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if masks[i][j][k] {
+					assert.True(t, s.Pruned)
+				}
+			}
+		}
+	}
+}
+
+func Test_PruneDump(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs:     3,
+		Layout:     []int{5, 2},
+		Activation: ActivationSigmoid,
+		Bias:       true,
+		Seed:       1,
+	})
+	assert.Nil(t, n.Dump().Pruned)
+
+	PruneGlobal(n, 0.5)
+	dump, err := n.Marshal()
+	assert.NoError(t, err)
+	restored, err := Unmarshal(dump)
+	assert.NoError(t, err)
+	assert.Equal(t, n.Masks(), restored.Masks())
+
+	input := []float64{0.1, -0.4, 0.7}
+	assert.Equal(t, n.Predict(input), restored.Predict(input))
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(dump, &raw))
+	assert.Contains(t, raw, "Pruned")
+}
+
+func Test_PruneNeurons(t *testing.T) {
+	c := &Config{
+		Inputs:     3,
+		Layout:     []int{6, 5, 2},
+		Activation: ActivationTanh,
+		Mode:       ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+	n := NewNeural(c)
+	shared := NewNeural(c)
+
+	// Neurons whose outputs are ignored can be removed without changing
+	// predictions
+	for _, j := range []int{1, 4} {
+		for _, s := range n.Layers[0].Neurons[j].Out[:5] {
+			s.Weight = 0
+		}
+	}
+	reference := FromDump(n.Dump())
+
+	assert.NoError(t, PruneNeurons(n, 0, 2))
+	assert.Equal(t, []int{4, 5, 2}, n.Config.Layout)
+	assert.Equal(t, []int{6, 5, 2}, shared.Config.Layout)
+	assert.Len(t, n.Layers[0].Neurons, 4)
+	for _, neuron := range n.Layers[1].Neurons {
+		assert.Len(t, neuron.In, 4+1+1)
+	}
+	for i := 0; i < 10; i++ {
+		input := []float64{rand.NormFloat64(), rand.NormFloat64(), rand.NormFloat64()}
+		assert.InDeltaSlice(t, reference.Predict(input), n.Predict(input), 1e-12)
+	}
+
+	restored := FromDump(n.Dump())
+	assert.Equal(t, n.Weights(), restored.Weights())
+
+	assert.Error(t, PruneNeurons(n, 2, 1))
+	assert.Error(t, PruneNeurons(n, 0, 4))
+}
+
+func Test_MaskNeurons(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs:     3,
+		Layout:     []int{6, 5, 2},
+		Activation: ActivationTanh,
+		Mode:       ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	})
+
+	// Masking leaves the topology alone and counts neurons masked before
+	assert.NoError(t, MaskNeurons(n, 0, 2))
+	assert.NoError(t, MaskNeurons(n, 0, 3))
+	assert.NoError(t, MaskNeurons(n, 1, 1))
+	assert.Equal(t, []int{6, 5, 2}, n.Config.Layout)
+	assert.Len(t, n.Layers[0].Neurons, 6)
+	reference := FromDump(n.Dump())
+
+	RemoveMaskedNeurons(n)
+	assert.Equal(t, []int{3, 4, 2}, n.Config.Layout)
+	for _, neuron := range n.Layers[1].Neurons {
+		assert.Len(t, neuron.In, 3+1+1)
+	}
+	for i := 0; i < 10; i++ {
+		input := []float64{rand.NormFloat64(), rand.NormFloat64(), rand.NormFloat64()}
+		assert.InDeltaSlice(t, reference.Predict(input), n.Predict(input), 1e-12)
+	}
+
+	assert.Error(t, MaskNeurons(n, 2, 1))
+	assert.Error(t, MaskNeurons(n, 0, 3))
+}
+
+func Test_Rewind(t *testing.T) {
+	n := NewNeural(&Config{
+		Inputs: 2,
+		Layout: []int{4, 1},
+		Bias:   true,
+		Seed:   3,
+	})
+	initial := n.Weights()
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.Weight += 1
+			}
+		}
+	}
+	PruneGlobal(n, 0.5)
+	n.Rewind(initial)
+
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if s.Pruned {
+					assert.Equal(t, 0.0, s.Weight)
+				} else {
+					assert.Equal(t, initial[i][j][k], s.Weight)
+				}
+			}
+		}
+	}
+}
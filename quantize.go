@@ -0,0 +1,322 @@
+package deep
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Granularity determines which weights share a quantization scale
+type Granularity int
+
+const (
+	// PerLayer quantizes all weights of a layer with one scale
+	PerLayer Granularity = 0
+	// PerChannel quantizes the weights feeding every neuron with its own scale
+	PerChannel Granularity = 1
+)
+
+// Quantized is an inference-only network with int8 weights and uint8
+// activations between layers, accumulating in int32. Biases and the
+// outputs of the last layer remain floating point.
+type Quantized struct {
+	Config *Config
+	Layers []*QuantizedLayer
+}
+
+// QuantizedLayer is a layer of a Quantized network
+type QuantizedLayer struct {
+	// Weights feeding every neuron, excluding the bias
+	Weights [][]int8
+	// Scale of the weights of every neuron, or of the layer
+	Scales []float32
+	// Biases of every neuron, if any
+	Biases []float32
+	// Affine quantization of the inputs of the layer
+	InScale float32
+	InZero  uint8
+
+	// Quantized outputs of the last pass, feeding the recurrent synapses
+	state []uint8
+}
+
+// Quantize converts n into an int8 network, calibrating the ranges of its
+// activations on a sample of inputs. The recurrent state of n is left as it was.
+func Quantize(n *Neural, calibration [][]float64, g Granularity) *Quantized {
+	// Range of the inputs of each layer, plus the outputs of the last
+	lo, hi := make([]float64, len(n.Layers)), make([]float64, len(n.Layers))
+	state := n.RecurrentState()
+	for _, x := range calibration {
+		n.Forward(x)
+		for i := range n.Layers {
+			in := x
+			if i > 0 {
+				in = layerValues(n.Layers[i-1])
+			}
+			for _, v := range in {
+				lo[i], hi[i] = math.Min(lo[i], v), math.Max(hi[i], v)
+			}
+		}
+	}
+	n.SetRecurrentState(state)
+
+	q := &Quantized{Config: n.Config, Layers: make([]*QuantizedLayer, len(n.Layers))}
+	for i, l := range n.Layers {
+		scales := weightScales(l, g)
+		ql := &QuantizedLayer{
+			Weights: make([][]int8, len(l.Neurons)),
+			Scales:  make([]float32, len(scales)),
+			state:   make([]uint8, len(l.Neurons)),
+		}
+		ql.InScale, ql.InZero = affineParams(lo[i], hi[i])
+		for j, s := range scales {
+			ql.Scales[j] = float32(s)
+		}
+		for j, neuron := range l.Neurons {
+			s := scales[j%len(scales)]
+			for _, syn := range neuron.In {
+				if syn.IsBias {
+					ql.Biases = append(ql.Biases, float32(syn.Weight))
+					continue
+				}
+				ql.Weights[j] = append(ql.Weights[j], quantizeWeight(syn.Weight, s))
+			}
+		}
+		q.Layers[i] = ql
+	}
+	q.reset()
+	return q
+}
+
+// reset sets the state feeding the recurrent synapses to zero
+func (q *Quantized) reset() {
+	for i := 0; i < len(q.Layers)-1; i++ {
+		for j := range q.Layers[i].state {
+			q.Layers[i].state[j] = q.Layers[i+1].InZero
+		}
+	}
+}
+
+// FakeQuantize returns the weights of n rounded to the int8 values that
+// Quantize would give them, for quantization-aware training
+func FakeQuantize(n *Neural, g Granularity) [][][]float64 {
+	weights := n.Weights()
+	for i, l := range n.Layers {
+		scales := weightScales(l, g)
+		for j, neuron := range l.Neurons {
+			s := scales[j%len(scales)]
+			for k, syn := range neuron.In {
+				if !syn.IsBias {
+					weights[i][j][k] = float64(quantizeWeight(syn.Weight, s)) * s
+				}
+			}
+		}
+	}
+	return weights
+}
+
+// weightScales returns the symmetric int8 scale of the weights of every
+// neuron in l, or of the whole layer
+func weightScales(l *Layer, g Granularity) []float64 {
+	scales := make([]float64, len(l.Neurons))
+	for j, neuron := range l.Neurons {
+		for _, syn := range neuron.In {
+			if !syn.IsBias {
+				scales[j] = math.Max(scales[j], math.Abs(syn.Weight)/127)
+			}
+		}
+	}
+	if g == PerLayer {
+		scales = []float64{Max(scales)}
+	}
+	for j := range scales {
+		if scales[j] == 0 {
+			scales[j] = 1
+		}
+	}
+	return scales
+}
+
+func quantizeWeight(w, scale float64) int8 {
+	return int8(math.Max(-127, math.Min(127, math.Round(w/scale))))
+}
+
+// affineParams returns the scale and zero point mapping [lo, hi] onto uint8,
+// where the range is widened to contain zero so that it is exactly representable
+func affineParams(lo, hi float64) (float32, uint8) {
+	lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	if hi == lo {
+		return 1, 0
+	}
+	scale := (hi - lo) / 255
+	return float32(scale), uint8(math.Round(-lo / scale))
+}
+
+func (l *QuantizedLayer) quantize(x float32) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(float64(x/l.InScale))+float64(l.InZero))))
+}
+
+// Forward computes a forward pass and returns the output of the last layer
+func (q *Quantized) Forward(input []float64) ([]float64, error) {
+	if len(input) != q.Config.Inputs {
+		return nil, fmt.Errorf("Invalid input dimension - expected: %d got: %d", q.Config.Inputs, len(input))
+	}
+
+	in := make([]uint8, len(input))
+	for i, x := range input {
+		in[i] = q.Layers[0].quantize(float32(x))
+	}
+
+	var out []float64
+	for i, l := range q.Layers {
+		out = make([]float64, len(l.Weights))
+		act := GetActivation(q.layerActivation(i))
+		for j, ws := range l.Weights {
+			var acc int32
+			for k, x := range in {
+				acc += (int32(x) - int32(l.InZero)) * int32(ws[k])
+			}
+			pre := float64(l.InScale) * float64(acc)
+			if len(ws) > len(in) {
+				// The recurrent synapse, fed by the last output quantized for the next layer
+				next := q.Layers[i+1]
+				pre += float64(next.InScale) * float64((int32(l.state[j])-int32(next.InZero))*int32(ws[len(in)]))
+			}
+			pre *= float64(l.Scales[j%len(l.Scales)])
+			if l.Biases != nil {
+				pre += float64(l.Biases[j])
+			}
+			out[j] = act.F(pre)
+		}
+		if q.layerActivation(i) == ActivationSoftmax {
+			out = Softmax(out)
+		}
+
+		if i < len(q.Layers)-1 {
+			next := q.Layers[i+1]
+			in = make([]uint8, len(out))
+			for j, v := range out {
+				in[j] = next.quantize(float32(v))
+			}
+			copy(l.state, in)
+		}
+	}
+	return out, nil
+}
+
+// Predict computes a forward pass and returns a prediction
+func (q *Quantized) Predict(input []float64) []float64 {
+	out, _ := q.Forward(input)
+	return out
+}
+
+func (q *Quantized) layerActivation(i int) ActivationType {
+	return q.Config.LayerActivation(i)
+}
+
+func layerValues(l *Layer) []float64 {
+	values := make([]float64, len(l.Neurons))
+	for i, n := range l.Neurons {
+		values[i] = n.Value
+	}
+	return values
+}
+
+// quantizedMagic identifies the binary form of a Quantized network
+var quantizedMagic = []byte("GDQ1")
+
+// MarshalBinary encodes q into a compact binary form: the JSON encoded
+// configuration followed by the raw weights, scales and biases of every layer
+func (q *Quantized) MarshalBinary() ([]byte, error) {
+	config, err := json.Marshal(q.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(quantizedMagic)
+	write := func(v interface{}) { binary.Write(&buf, binary.LittleEndian, v) }
+	write(uint32(len(config)))
+	buf.Write(config)
+	write(uint32(len(q.Layers)))
+	for _, l := range q.Layers {
+		write(uint32(len(l.Weights)))
+		write(uint32(len(l.Weights[0])))
+		for _, ws := range l.Weights {
+			write(ws)
+		}
+		write(uint32(len(l.Scales)))
+		write(l.Scales)
+		write(uint32(len(l.Biases)))
+		write(l.Biases)
+		write(l.InScale)
+		write(l.InZero)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores q from the form encoded by MarshalBinary
+func (q *Quantized) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, quantizedMagic) {
+		return errors.New("Invalid quantized network encoding")
+	}
+	r := bytes.NewReader(data[len(quantizedMagic):])
+	var err error
+	read := func(v interface{}) {
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, v)
+		}
+	}
+	// length reads the number of elements of size bytes which follow, and
+	// rejects those that the remaining data cannot hold before they are
+	// allocated
+	length := func(size int) int {
+		var n uint32
+		read(&n)
+		if err == nil && size > 0 && int(n) > r.Len()/size {
+			err = errors.New("Invalid quantized network encoding")
+		}
+		if err != nil {
+			return 0
+		}
+		return int(n)
+	}
+
+	config := make([]byte, length(1))
+	read(config)
+	if err != nil {
+		return err
+	}
+	q.Config = &Config{}
+	if err := json.Unmarshal(config, q.Config); err != nil {
+		return err
+	}
+
+	// Every layer holds at least four lengths and its input scale and zero
+	q.Layers = make([]*QuantizedLayer, length(4*4+4+1))
+	for i := range q.Layers {
+		l := &QuantizedLayer{}
+		neurons := length(1)
+		synapses := length(neurons)
+		l.Weights = make([][]int8, neurons)
+		for j := range l.Weights {
+			l.Weights[j] = make([]int8, synapses)
+			read(l.Weights[j])
+		}
+		l.Scales = make([]float32, length(4))
+		read(l.Scales)
+		if biases := length(4); biases > 0 {
+			l.Biases = make([]float32, biases)
+			read(l.Biases)
+		}
+		read(&l.InScale)
+		read(&l.InZero)
+		l.state = make([]uint8, neurons)
+		q.Layers[i] = l
+	}
+	q.reset()
+	return err
+}
@@ -0,0 +1,113 @@
+package deep
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomInputs(n, dim int) [][]float64 {
+	inputs := make([][]float64, n)
+	for i := range inputs {
+		inputs[i] = make([]float64, dim)
+		for j := range inputs[i] {
+			inputs[i][j] = rand.NormFloat64()
+		}
+	}
+	return inputs
+}
+
+func Test_Quantize(t *testing.T) {
+	rand.Seed(0)
+	c := &Config{
+		Inputs:     4,
+		Layout:     []int{16, 8, 3},
+		Activation: ActivationTanh,
+		Mode:       ModeMultiClass,
+		Init:       &Initializer{Kind: "glorot_normal"},
+		Bias:       true,
+	}
+	n := NewNeural(c)
+	calibration := randomInputs(200, 4)
+
+	for _, g := range []Granularity{PerLayer, PerChannel} {
+		reference := FromDump(n.Dump())
+		q := Quantize(FromDump(n.Dump()), calibration, g)
+
+		var maxErr float64
+		for _, x := range randomInputs(100, 4) {
+			y, yq := reference.Predict(x), q.Predict(x)
+			for i := range y {
+				maxErr = math.Max(maxErr, math.Abs(y[i]-yq[i]))
+			}
+		}
+		assert.True(t, maxErr < 0.05, "granularity %d error %f", g, maxErr)
+	}
+
+	n.Forward(calibration[0])
+	state := n.RecurrentState()
+	_, err := Quantize(n, calibration, PerLayer).Forward([]float64{1})
+	assert.Error(t, err)
+	assert.Equal(t, state, n.RecurrentState())
+}
+
+func Test_FakeQuantize(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&Config{
+		Inputs: 3,
+		Layout: []int{4, 2},
+		Init:   &Initializer{Kind: "normal", Std: 1},
+		Bias:   true,
+	})
+	q := Quantize(n, randomInputs(10, 3), PerChannel)
+
+	fake := FakeQuantize(n, PerChannel)
+	for i, l := range q.Layers {
+		for j, ws := range l.Weights {
+			for k, w := range ws {
+				assert.InDelta(t, float64(w)*float64(l.Scales[j]), fake[i][j][k], 1e-6)
+			}
+			if l.Biases != nil {
+				assert.Equal(t, n.Biases[i][j].Weight, fake[i][j][len(ws)])
+			}
+		}
+	}
+}
+
+func Test_QuantizedMarshal(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&Config{
+		Inputs:     10,
+		Layout:     []int{20, 5},
+		Activation: ActivationReLU,
+		Mode:       ModeRegression,
+		Bias:       true,
+	})
+	q := Quantize(n, randomInputs(50, 10), PerChannel)
+
+	b, err := q.MarshalBinary()
+	assert.Nil(t, err)
+	js, err := n.Marshal()
+	assert.Nil(t, err)
+	assert.True(t, len(b) < len(js)/4, "%d bytes against %d", len(b), len(js))
+
+	restored := &Quantized{}
+	assert.Nil(t, restored.UnmarshalBinary(b))
+	assert.Equal(t, q.Layers, restored.Layers)
+	for _, x := range randomInputs(10, 10) {
+		assert.Equal(t, q.Predict(x), restored.Predict(x))
+	}
+
+	assert.Error(t, restored.UnmarshalBinary([]byte("nope")))
+	assert.Error(t, restored.UnmarshalBinary(b[:len(b)/2]))
+
+	// Lengths beyond the data are rejected before they are allocated
+	assert.Error(t, restored.UnmarshalBinary(append([]byte("GDQ1"), 0xff, 0xff, 0xff, 0xff)))
+	huge := append([]byte{}, b...)
+	config := int(binary.LittleEndian.Uint32(huge[4:]))
+	binary.LittleEndian.PutUint32(huge[4+4+config+4:], math.MaxUint32)
+	assert.Error(t, restored.UnmarshalBinary(huge))
+}
@@ -0,0 +1,73 @@
+package deep
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// SharedWeights are the weights of networks of the same configuration, which
+// goroutines read and update atomically, but without locks, such as in
+// Hogwild training. They are laid out in the order of the weights of Weights.
+type SharedWeights []uint64
+
+// NewSharedWeights returns shared weights set to those of n
+func NewSharedWeights(n *Neural) SharedWeights {
+	w := make(SharedWeights, n.NumWeights())
+	w.Store(n)
+	return w
+}
+
+// Store sets w to the weights of n
+func (w SharedWeights) Store(n *Neural) {
+	var idx int
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				atomic.StoreUint64(&w[idx], math.Float64bits(s.Weight))
+				idx++
+			}
+		}
+	}
+}
+
+// Load sets the weights of n to w
+func (w SharedWeights) Load(n *Neural) {
+	var idx int
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.Weight = math.Float64frombits(atomic.LoadUint64(&w[idx]))
+				idx++
+			}
+		}
+	}
+}
+
+// Add adds update to the idx-th weight, unless that makes it NaN
+func (w SharedWeights) Add(idx int, update float64) {
+	for {
+		old := atomic.LoadUint64(&w[idx])
+		weight := math.Float64frombits(old) + update
+		if math.IsNaN(weight) || atomic.CompareAndSwapUint64(&w[idx], old, math.Float64bits(weight)) {
+			return
+		}
+	}
+}
+
+// Share makes the forward and backward passes of n read its weights from w
+// in place, as they are updated, rather than from its synapses, or from its
+// synapses again when w is nil
+func (n *Neural) Share(w SharedWeights) {
+	var idx int
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.shared = nil
+				if w != nil {
+					s.shared = &w[idx]
+				}
+				idx++
+			}
+		}
+	}
+}
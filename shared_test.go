@@ -0,0 +1,33 @@
+package deep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SharedWeights(t *testing.T) {
+	c := &Config{Inputs: 2, Layout: []int{3, 2}, Activation: ActivationTanh, Mode: ModeMultiClass, Bias: true, Seed: 1}
+	n, other := NewNeural(c), NewNeural(&Config{Inputs: 2, Layout: []int{3, 2}, Activation: ActivationTanh, Mode: ModeMultiClass, Bias: true, Seed: 2})
+	w := NewSharedWeights(n)
+	input := []float64{0.5, -1}
+	expected := n.Predict(input)
+
+	// A sharing network computes with the shared weights, not its own
+	other.Share(w)
+	assert.Equal(t, expected, other.Predict(input))
+	other.Backward([]float64{1, 0})
+	n.Backward([]float64{1, 0})
+	assert.Equal(t, n.Gradients(), other.Gradients())
+
+	// and follows their updates
+	w.Add(0, 1)
+	n.Layers[0].Neurons[0].In[0].Weight++
+	assert.Equal(t, n.Predict(input), other.Predict(input))
+
+	w.Load(other)
+	assert.Equal(t, n.Weights(), other.Weights())
+	other.Share(nil)
+	other.Layers[0].Neurons[0].In[0].Weight = 0
+	assert.NotEqual(t, n.Predict(input), other.Predict(input))
+}
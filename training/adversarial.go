@@ -0,0 +1,209 @@
+package training
+
+import (
+	"math"
+	"math/rand"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Norm measures the size of a perturbation of an input
+type Norm int
+
+const (
+	// LInf is the largest change of any element
+	LInf Norm = 0
+	// L2 is the euclidean length
+	L2 Norm = 1
+)
+
+// Attack perturbs the input of an example to raise the loss of a network on
+// it, within a budget
+type Attack interface {
+	Perturb(n *deep.Neural, e Example) []float64
+}
+
+// FGSM is the fast gradient sign method (Goodfellow et al., 2015), a single
+// step of size Epsilon along the sign of the gradient of the loss with
+// respect to the input for LInf, or along the gradient itself for L2.
+// Perturbed inputs are clamped to [Min, Max] unless both are zero.
+type FGSM struct {
+	Epsilon  float64
+	Norm     Norm
+	Min, Max float64
+}
+
+// Perturb returns the input of e perturbed to raise the loss of n on it
+func (a FGSM) Perturb(n *deep.Neural, e Example) []float64 {
+	x := append([]float64{}, e.Input...)
+	ascend(x, lossGradient(n, e, x), a.Epsilon, a.Norm)
+	clamp(x, a.Min, a.Max)
+	return x
+}
+
+// PGD is projected gradient descent (Madry et al., 2018), Steps steps like
+// FGSM of size Step, each projected back to within Epsilon of the input and
+// clamped to [Min, Max] unless both are zero. If Rand is set, the attack
+// starts from a uniformly random point within Epsilon drawn from it.
+type PGD struct {
+	Epsilon, Step float64
+	Steps         int
+	Norm          Norm
+	Min, Max      float64
+	Rand          *rand.Rand
+}
+
+// Perturb returns the input of e perturbed to raise the loss of n on it
+func (a PGD) Perturb(n *deep.Neural, e Example) []float64 {
+	x := append([]float64{}, e.Input...)
+	if a.Rand != nil {
+		start(x, a.Rand, a.Epsilon, a.Norm)
+		clamp(x, a.Min, a.Max)
+	}
+	for i := 0; i < a.Steps; i++ {
+		ascend(x, lossGradient(n, e, x), a.Step, a.Norm)
+		project(x, e.Input, a.Epsilon, a.Norm)
+		clamp(x, a.Min, a.Max)
+	}
+	return x
+}
+
+// lossGradient returns the gradient of the loss of n on e with respect to its
+// input at x. It is evaluated in the current recurrent state of n, which is
+// left as it was along with the gradients accumulated in n.
+func lossGradient(n *deep.Neural, e Example, x []float64) []float64 {
+	state, gradients := n.RecurrentState(), n.Gradients()
+	defer func() {
+		n.SetRecurrentState(state)
+		n.SetGradients(gradients)
+	}()
+
+	n.Forward(x)
+	deltas := make([]float64, len(n.Layers[len(n.Layers)-1].Neurons))
+	outputDeltas(n, e, deltas)
+	return n.BackwardDeltas(deltas)
+}
+
+// ascend moves x a step of size along grad, measured in norm
+func ascend(x, grad []float64, size float64, norm Norm) {
+	switch norm {
+	case LInf:
+		for k, g := range grad {
+			x[k] += size * deep.Sgn(g)
+		}
+	case L2:
+		length := math.Sqrt(deep.Dot(grad, grad))
+		if length == 0 {
+			return
+		}
+		for k, g := range grad {
+			x[k] += size * g / length
+		}
+	}
+}
+
+// project moves x back to within epsilon of origin, measured in norm
+func project(x, origin []float64, epsilon float64, norm Norm) {
+	switch norm {
+	case LInf:
+		for k, o := range origin {
+			x[k] = math.Max(o-epsilon, math.Min(o+epsilon, x[k]))
+		}
+	case L2:
+		var length float64
+		for k, o := range origin {
+			length += (x[k] - o) * (x[k] - o)
+		}
+		length = math.Sqrt(length)
+		if length <= epsilon {
+			return
+		}
+		for k, o := range origin {
+			x[k] = o + (x[k]-o)*epsilon/length
+		}
+	}
+}
+
+// start moves x to a uniformly random point within epsilon of it, measured in
+// norm
+func start(x []float64, r *rand.Rand, epsilon float64, norm Norm) {
+	switch norm {
+	case LInf:
+		for k := range x {
+			x[k] += epsilon * (2*r.Float64() - 1)
+		}
+	case L2:
+		direction := make([]float64, len(x))
+		for k := range direction {
+			direction[k] = r.NormFloat64()
+		}
+		length := math.Sqrt(deep.Dot(direction, direction))
+		radius := epsilon * math.Pow(r.Float64(), 1/float64(len(x)))
+		for k, d := range direction {
+			x[k] += radius * d / length
+		}
+	}
+}
+
+// clamp limits the elements of x to [min, max] unless both are zero
+func clamp(x []float64, min, max float64) {
+	if min == 0 && max == 0 {
+		return
+	}
+	for k, v := range x {
+		x[k] = math.Max(min, math.Min(max, v))
+	}
+}
+
+// Robustness is the accuracy of a network on examples attacked within a
+// budget
+type Robustness struct {
+	Epsilon  float64
+	Accuracy float64
+}
+
+// EvaluateRobustness returns the accuracy of n on examples attacked by
+// attack(epsilon), for every budget epsilon
+func EvaluateRobustness(n *deep.Neural, examples Examples, attack func(epsilon float64) Attack, epsilons []float64) []Robustness {
+	robustness := make([]Robustness, len(epsilons))
+	for i, epsilon := range epsilons {
+		a := attack(epsilon)
+		attacked := make(Examples, len(examples))
+		for j, e := range examples {
+			attacked[j] = e
+			attacked[j].Input = a.Perturb(n, e)
+		}
+		robustness[i] = Robustness{Epsilon: epsilon, Accuracy: accuracy(n, attacked)}
+	}
+	return robustness
+}
+
+// adversarial trains on examples attacked against the network being trained
+type adversarial struct {
+	attack Attack
+	ratio  float64
+}
+
+// WithAdversarialTraining makes OnlineTrainer and BatchTrainer replace every
+// example they learn, with probability ratio, by its perturbation by attack
+// against the current weights, so that each batch mixes attacked examples
+// with clean ones
+func WithAdversarialTraining(attack Attack, ratio float64) Option {
+	return func(o *options) { o.adversarial = &adversarial{attack: attack, ratio: ratio} }
+}
+
+// perturb returns a copy of examples in which those drawn for adversarial
+// training are attacked against n
+func (o *options) perturb(n *deep.Neural, examples Examples) Examples {
+	if o.adversarial == nil {
+		return examples
+	}
+	perturbed := make(Examples, len(examples))
+	for i, e := range examples {
+		perturbed[i] = e
+		if o.rand.Float64() < o.adversarial.ratio {
+			perturbed[i].Input = o.adversarial.attack.Perturb(n, e)
+		}
+	}
+	return perturbed
+}
@@ -0,0 +1,146 @@
+package training
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+// blobs returns examples of two classes of points in the plane, centered at
+// (-1, -1) and (1, 1)
+func blobs(count int, seed int64) Examples {
+	r := rand.New(rand.NewSource(seed))
+	examples := make(Examples, count)
+	for i := range examples {
+		c := i % 2
+		center := float64(2*c - 1)
+		examples[i] = Example{
+			Input:    []float64{center + 0.5*r.NormFloat64(), center + 0.5*r.NormFloat64()},
+			Response: make([]float64, 2),
+		}
+		examples[i].Response[c] = 1
+	}
+	return examples
+}
+
+func Test_Attacks(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     4,
+		Layout:     []int{3},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Init:       &deep.Initializer{Kind: "normal", Std: 1},
+		Bias:       true,
+		Seed:       1,
+	})
+	e := Example{Input: []float64{0.2, 0.5, 0.1, 0.9}, Response: []float64{0, 1, 0}}
+	loss := func(x []float64) float64 {
+		attacked := e
+		attacked.Input = x
+		return crossValidate(n, Examples{attacked})
+	}
+	distance := func(x []float64, norm Norm) float64 {
+		var d float64
+		for k, v := range x {
+			if norm == LInf {
+				d = math.Max(d, math.Abs(v-e.Input[k]))
+			} else {
+				d += (v - e.Input[k]) * (v - e.Input[k])
+			}
+		}
+		if norm == L2 {
+			d = math.Sqrt(d)
+		}
+		return d
+	}
+
+	for _, norm := range []Norm{LInf, L2} {
+		fgsm := FGSM{Epsilon: 0.1, Norm: norm}.Perturb(n, e)
+		assert.InDelta(t, 0.1, distance(fgsm, norm), 1e-12)
+		assert.True(t, loss(fgsm) > loss(e.Input))
+
+		pgd := PGD{Epsilon: 0.1, Step: 0.03, Steps: 10, Norm: norm, Rand: rand.New(rand.NewSource(1))}.Perturb(n, e)
+		assert.True(t, distance(pgd, norm) <= 0.1+1e-12)
+		assert.True(t, loss(pgd) > loss(e.Input))
+	}
+
+	clamped := FGSM{Epsilon: 0.2, Min: 0, Max: 1}.Perturb(n, e)
+	for _, v := range clamped {
+		assert.True(t, v >= 0 && v <= 1)
+	}
+}
+
+func Test_AttackKeepsState(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	})
+	e := blobs(1, 1)[0]
+	n.Predict(e.Input)
+	n.Layers[0].Neurons[0].In[0].Gradient = 0.5
+	state, gradients := n.RecurrentState(), n.Gradients()
+
+	PGD{Epsilon: 0.5, Step: 0.1, Steps: 5}.Perturb(n, e)
+	assert.Equal(t, state, n.RecurrentState())
+	assert.Equal(t, gradients, n.Gradients())
+}
+
+func Test_AdversarialTraining(t *testing.T) {
+	train, test := blobs(400, 1), blobs(200, 2)
+	attack := func(epsilon float64) Attack {
+		return PGD{Epsilon: epsilon, Step: epsilon / 4, Steps: 10}
+	}
+	epsilons := []float64{0, 0.5, 1}
+
+	robustness := func(opts ...Option) []Robustness {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{8, 2},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Bias:       true,
+			Seed:       1,
+		})
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 20, 2, append(opts, WithSeed(1))...).Train(n, train, nil, 30)
+		r := EvaluateRobustness(n, test, attack, epsilons)
+		assert.InDelta(t, accuracy(n, test), r[0].Accuracy, 0.02)
+		return r
+	}
+
+	clean := robustness()
+	hardened := robustness(WithAdversarialTraining(attack(0.5), 0.5))
+	for i, r := range clean {
+		assert.Equal(t, epsilons[i], r.Epsilon)
+		if i > 0 {
+			assert.True(t, r.Accuracy <= clean[i-1].Accuracy)
+		}
+	}
+	assert.True(t, clean[0].Accuracy > 0.9)
+	assert.True(t, hardened[0].Accuracy > 0.9)
+	// Hardened against the budget it was trained with
+	assert.True(t, hardened[1].Accuracy > clean[1].Accuracy)
+}
+
+func Test_Perturb(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{2}, Mode: deep.ModeMultiClass, Seed: 1})
+	examples := blobs(10, 1)
+	inputs := examples.Inputs()
+
+	o := newOptions([]Option{WithAdversarialTraining(FGSM{Epsilon: 0.1}, 1)})
+	perturbed := o.perturb(n, examples)
+	for i, e := range perturbed {
+		assert.NotEqual(t, inputs[i], e.Input)
+		assert.Equal(t, inputs[i], examples[i].Input)
+		assert.Equal(t, examples[i].Response, e.Response)
+	}
+
+	o = newOptions([]Option{WithAdversarialTraining(FGSM{Epsilon: 0.1}, 0)})
+	assert.Equal(t, examples, o.perturb(n, examples))
+}
@@ -3,7 +3,6 @@ package training
 import (
 	"math"
 	"sync"
-	"time"
 
 	deep "github.com/Maxime2/go-deep"
 )
@@ -11,6 +10,7 @@ import (
 // BatchTrainer implements parallelized batch training
 type BatchTrainer struct {
 	*internalb
+	options
 	verbosity   int
 	batchSize   int
 	parallelism int
@@ -19,40 +19,33 @@ type BatchTrainer struct {
 }
 
 type internalb struct {
-	deltas            [][][]float64
-	partialDeltas     [][][][]float64
+	deltas            [][]float64
 	accumulatedDeltas [][][]float64
 	moments           [][][]float64
 }
 
 func newBatchTraining(layers []*deep.Layer, parallelism int) *internalb {
-	deltas := make([][][]float64, parallelism)
-	partialDeltas := make([][][][]float64, parallelism)
+	deltas := make([][]float64, parallelism)
+	for w := range deltas {
+		deltas[w] = make([]float64, len(layers[len(layers)-1].Neurons))
+	}
 	accumulatedDeltas := make([][][]float64, len(layers))
-	for w := 0; w < parallelism; w++ {
-		deltas[w] = make([][]float64, len(layers))
-		partialDeltas[w] = make([][][]float64, len(layers))
-
-		for i, l := range layers {
-			deltas[w][i] = make([]float64, len(l.Neurons))
-			accumulatedDeltas[i] = make([][]float64, len(l.Neurons))
-			partialDeltas[w][i] = make([][]float64, len(l.Neurons))
-			for j, n := range l.Neurons {
-				partialDeltas[w][i][j] = make([]float64, len(n.In))
-				accumulatedDeltas[i][j] = make([]float64, len(n.In))
-			}
+	for i, l := range layers {
+		accumulatedDeltas[i] = make([][]float64, len(l.Neurons))
+		for j, n := range l.Neurons {
+			accumulatedDeltas[i][j] = make([]float64, len(n.In))
 		}
 	}
 	return &internalb{
 		deltas:            deltas,
-		partialDeltas:     partialDeltas,
 		accumulatedDeltas: accumulatedDeltas,
 	}
 }
 
 // NewBatchTrainer returns a BatchTrainer
-func NewBatchTrainer(solver Solver, verbosity, batchSize, parallelism int) *BatchTrainer {
+func NewBatchTrainer(solver Solver, verbosity, batchSize, parallelism int, opts ...Option) *BatchTrainer {
 	return &BatchTrainer{
+		options:     newOptions(opts),
 		solver:      solver,
 		verbosity:   verbosity,
 		batchSize:   iparam(batchSize, 1),
@@ -66,117 +59,153 @@ func (t *BatchTrainer) Train(n *deep.Neural, examples, validation Examples, iter
 	t.internalb = newBatchTraining(n.Layers, t.parallelism)
 
 	train := make(Examples, len(examples))
-	copy(train, examples)
+	copy(train, t.teach(n, examples))
 
-	workCh := make(chan Example, t.parallelism)
 	nets := make([]*deep.Neural, t.parallelism)
-
-	wg := sync.WaitGroup{}
-	for i := 0; i < t.parallelism; i++ {
+	for i := range nets {
 		nets[i] = deep.NewNeural(n.Config)
-
-		go func(id int, workCh <-chan Example) {
-			n := nets[id]
-			for e := range workCh {
-				n.Forward(e.Input)
-				t.calculateDeltas(n, e.Response, id)
-				wg.Done()
-			}
-		}(i, workCh)
 	}
 
-	t.printer.Init(n)
+	t.printer.teacher = t.teacher()
 	t.solver.Init(n.NumWeights())
-
-	ts := time.Now()
+	initRegularization(t.solver, n)
+	t.printer.regularizer = regularizerOf(t.solver)
+	t.initClipping()
+	t.printer.clipping = t.clipping
+	t.initPrivacy(n, train, t.batchSize, len(nets))
+	t.printer.privacy = t.privacy
+	t.printer.Verbosity = t.verbosity
+
+	p := t.begin(n, validation, t.printer)
 	for it := 1; it <= iterations; it++ {
-		train.Shuffle()
+		p.epochBegin(n, it)
+		train.ShuffleWith(t.rand)
 		batches := train.SplitSize(t.batchSize)
 
+		var steps int
 		for _, b := range batches {
-			currentWeights := n.Weights()
-			for _, n := range nets {
-				n.ApplyWeights(currentWeights)
+			b = t.perturb(n, b)
+			t.accumulate(n, nets, b)
+			t.privatize(n, t.accumulatedDeltas)
+			t.approach(n, t.accumulatedDeltas, len(b))
+			regularizeExamples(t.solver, len(b))
+			t.update(n, it)
+			steps++
+			if p.batchEnd(n, steps) {
+				break
 			}
+		}
+		t.account(steps)
+		t.prune(n, it)
+		if p.epochEnd(n) {
+			break
+		}
+	}
+	p.trainEnd(n)
+}
 
-			wg.Add(len(b))
-			for _, item := range b {
-				workCh <- item
+// accumulate adds the gradients of the loss on b, computed by nets in
+// parallel with the weights of n, to the accumulated deltas
+func (t *BatchTrainer) accumulate(n *deep.Neural, nets []*deep.Neural, b Examples) {
+	currentWeights := t.weights(n)
+	grads := batchGradients(n, b)
+
+	// Every worker backpropagates a fixed, contiguous part of the batch,
+	// and partial deltas are reduced in worker order, so that the sums
+	// do not depend on scheduling
+	wg := sync.WaitGroup{}
+	wg.Add(len(nets))
+	for w := range nets {
+		go func(w int) {
+			defer wg.Done()
+			n := nets[w]
+			n.ApplyWeights(currentWeights)
+			for i := w * len(b) / len(nets); i < (w+1)*len(b)/len(nets); i++ {
+				j := job{e: b[i]}
+				if grads != nil {
+					j.grad = grads[i]
+				}
+				n.Forward(j.e.Input)
+				t.calculateDeltas(n, j, w)
+				t.clipExample(n, w)
 			}
-			wg.Wait()
-
-			for _, wPD := range t.partialDeltas {
-				for i, iPD := range wPD {
-					iAD := t.accumulatedDeltas[i]
-					for j, jPD := range iPD {
-						jAD := iAD[j]
-						for k, v := range jPD {
-							jAD[k] += v
-							jPD[k] = 0
-						}
-					}
+			t.sumExamples(n, w)
+		}(w)
+	}
+	wg.Wait()
+
+	for _, net := range nets {
+		for i, l := range net.Layers {
+			iAD := t.accumulatedDeltas[i]
+			for j, neuron := range l.Neurons {
+				jAD := iAD[j]
+				for k, s := range neuron.In {
+					jAD[k] += s.Gradient
+					s.Gradient = 0
 				}
 			}
-
-			t.update(n, it)
 		}
+	}
+}
 
-		if t.verbosity > 0 && it%t.verbosity == 0 && len(validation) > 0 {
-			t.printer.PrintProgress(n, validation, time.Since(ts), it)
-		}
+// job is an example to be backpropagated, along with the gradient of the
+// loss with respect to its outputs when that depends on the whole batch
+type job struct {
+	e    Example
+	grad []float64
+}
+
+// batchGradients returns the gradient of a BatchLoss with respect to the outputs
+// of every example in b, or nil when the loss of n decomposes per example
+func batchGradients(n *deep.Neural, b Examples) [][]float64 {
+	loss, ok := deep.GetLoss(n.Config.Loss).(deep.BatchLoss)
+	if !ok {
+		return nil
+	}
+	estimates := make([][]float64, len(b))
+	for i, e := range b {
+		estimates[i] = n.Predict(e.Input)
 	}
+	ideal, weights, mask := b.targets()
+	return loss.BatchDf(estimates, ideal, weights, mask)
 }
 
-func (t *BatchTrainer) calculateDeltas(n *deep.Neural, ideal []float64, wid int) {
+func (t *BatchTrainer) calculateDeltas(n *deep.Neural, j job, wid int) {
 	loss := deep.GetLoss(n.Config.Loss)
 	deltas := t.deltas[wid]
-	partialDeltas := t.partialDeltas[wid]
-	lastDeltas := deltas[len(n.Layers)-1]
 
 	for i, n := range n.Layers[len(n.Layers)-1].Neurons {
-		lastDeltas[i] = loss.Df(
-			n.Value,
-			ideal[i],
-			n.DActivate(n.Value))
-	}
-
-	for i := len(n.Layers) - 2; i >= 0; i-- {
-		l := n.Layers[i]
-		iD := deltas[i]
-		nextD := deltas[i+1]
-		for j, n := range l.Neurons {
-			var sum float64
-			for k, s := range n.Out {
-				sum += s.Weight * nextD[k]
-			}
-			if math.IsNaN(n.DActivate(n.Value) * sum) {
-				iD[j] = n.DActivate(n.Value)
-			} else {
-				iD[j] = n.DActivate(n.Value) * sum
-			}
+		if j.grad != nil {
+			deltas[i] = j.grad[i] * n.DActivate(n.Value)
+			continue
 		}
-	}
-
-	for i, l := range n.Layers {
-		iD := deltas[i]
-		iPD := partialDeltas[i]
-		for j, n := range l.Neurons {
-			jD := iD[j]
-			jPD := iPD[j]
-			for k, s := range n.In {
-				jPD[k] += jD * s.In
-			}
+		deltas[i] = 0
+		if j.e.observed(i) {
+			deltas[i] = j.e.EffectiveWeight() * loss.Df(
+				n.Value,
+				j.e.Response[i],
+				n.DActivate(n.Value))
 		}
 	}
+	t.distill(n, j.e, deltas)
+
+	n.BackwardDeltas(deltas)
 }
 
 func (t *BatchTrainer) update(n *deep.Neural, it int) {
+	t.clip(n, t.accumulatedDeltas)
+
 	var idx int
 	for i, l := range n.Layers {
 		iAD := t.accumulatedDeltas[i]
 		for j, n := range l.Neurons {
 			jAD := iAD[j]
 			for k, s := range n.In {
+				if s.Pruned {
+					jAD[k] = 0
+					idx++
+					continue
+				}
 				update := t.solver.Update(s.Weight,
 					jAD[k],
 					s.In,
@@ -190,4 +219,5 @@ func (t *BatchTrainer) update(n *deep.Neural, it int) {
 			}
 		}
 	}
+	t.constrain(n)
 }
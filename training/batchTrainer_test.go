@@ -6,8 +6,30 @@ import (
 	"testing"
 
 	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
 )
 
+func Test_BatchTrainerReproducible(t *testing.T) {
+	train := func() [][][]float64 {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{8, 8, 1},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeBinary,
+			Bias:       true,
+			Seed:       42,
+		})
+		trainer := NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 7, 4, WithSeed(7))
+		trainer.Train(n, append(Examples{}, data...), nil, 50)
+		return n.Weights()
+	}
+
+	first := train()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, train())
+	}
+}
+
 func Benchmark_xor(b *testing.B) {
 	rand.Seed(0)
 	n := deep.NewNeural(&deep.Config{
@@ -19,10 +41,10 @@ func Benchmark_xor(b *testing.B) {
 		Bias:       true,
 	})
 	exs := Examples{
-		{[]float64{0, 0}, []float64{0}},
-		{[]float64{1, 0}, []float64{1}},
-		{[]float64{0, 1}, []float64{1}},
-		{[]float64{1, 1}, []float64{0}},
+		{Input: []float64{0, 0}, Response: []float64{0}},
+		{Input: []float64{1, 0}, Response: []float64{1}},
+		{Input: []float64{0, 1}, Response: []float64{1}},
+		{Input: []float64{1, 1}, Response: []float64{0}},
 	}
 	const minExamples = 4000
 	var dupExs Examples
@@ -0,0 +1,194 @@
+package training
+
+import (
+	"math"
+	"time"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Callback is notified of the progress of training a network, and may stop
+// it by returning true at the end of a batch or an epoch. Epochs and batches
+// are numbered from 1, and a batch of online training is a single example.
+type Callback interface {
+	OnTrainBegin(n *deep.Neural, m *Metrics)
+	OnEpochBegin(n *deep.Neural, epoch int, m *Metrics)
+	OnBatchEnd(n *deep.Neural, epoch, batch int, m *Metrics) bool
+	OnEpochEnd(n *deep.Neural, epoch int, m *Metrics) bool
+	OnTrainEnd(n *deep.Neural, epoch int, m *Metrics)
+}
+
+// BaseCallback does nothing, and is embedded by callbacks which need only
+// some of the methods of Callback
+type BaseCallback struct{}
+
+// OnTrainBegin does nothing
+func (BaseCallback) OnTrainBegin(n *deep.Neural, m *Metrics) {}
+
+// OnEpochBegin does nothing
+func (BaseCallback) OnEpochBegin(n *deep.Neural, epoch int, m *Metrics) {}
+
+// OnBatchEnd does nothing
+func (BaseCallback) OnBatchEnd(n *deep.Neural, epoch, batch int, m *Metrics) bool { return false }
+
+// OnEpochEnd does nothing
+func (BaseCallback) OnEpochEnd(n *deep.Neural, epoch int, m *Metrics) bool { return false }
+
+// OnTrainEnd does nothing
+func (BaseCallback) OnTrainEnd(n *deep.Neural, epoch int, m *Metrics) {}
+
+// WithCallbacks makes the trainer notify callbacks, after its StatsPrinter,
+// of the progress of training. OnlineTrainer and BatchTrainer notify them of
+// every batch, HogwildTrainer only of epochs.
+func WithCallbacks(callbacks ...Callback) Option {
+	return func(o *options) { o.callbacks = append(o.callbacks, callbacks...) }
+}
+
+// Metrics measure the network being trained. Those on the validation
+// examples are computed when called for, which takes a pass over them.
+type Metrics struct {
+	// Elapsed is the time since training began
+	Elapsed time.Duration
+	// Validation are the examples the network is measured on
+	Validation Examples
+
+	n           *deep.Neural
+	teacher     *deep.Neural
+	regularizer *Regularizer
+	clipping    *clipping
+	privacy     *privacy
+}
+
+// Loss returns the loss on the validation examples, with the penalty of the
+// regularizer of the solver, if any. Like the other metrics predicting the
+// validation examples, it leaves the recurrent state of the network as it
+// was, so that measuring does not change training.
+func (m *Metrics) Loss() float64 {
+	defer restore(m.n)()
+	loss := crossValidate(m.n, m.Validation)
+	if m.regularizer != nil {
+		loss += m.regularizer.Penalty(m.n)
+	}
+	return loss
+}
+
+// Accuracy returns the fraction of the validation examples whose class is
+// predicted
+func (m *Metrics) Accuracy() float64 {
+	defer restore(m.n)()
+	return accuracy(m.n, m.Validation)
+}
+
+// Agreement returns the fraction of the validation examples whose
+// prediction agrees with that of the teacher distilled from, or NaN without
+// distillation
+func (m *Metrics) Agreement() float64 {
+	if m.teacher == nil {
+		return math.NaN()
+	}
+	defer restore(m.n, m.teacher)()
+	return agreement(m.n, m.teacher, m.Validation)
+}
+
+// restore returns a function restoring the recurrent state of nets as it is
+// now
+func restore(nets ...*deep.Neural) func() {
+	states := make([][]float64, len(nets))
+	for i, n := range nets {
+		states[i] = n.RecurrentState()
+	}
+	return func() {
+		for i, n := range nets {
+			n.SetRecurrentState(states[i])
+		}
+	}
+}
+
+// Clipped returns the fraction of updates whose gradients were clipped, or
+// NaN without clipping
+func (m *Metrics) Clipped() float64 {
+	if m.clipping == nil {
+		return math.NaN()
+	}
+	return float64(m.clipping.clipped) / float64(m.clipping.updates)
+}
+
+// Epsilon returns the privacy spent, as ε at the δ of the options, or NaN
+// without privacy
+func (m *Metrics) Epsilon() float64 {
+	if m.privacy == nil || len(m.privacy.epsilons) == 0 {
+		return math.NaN()
+	}
+	return m.privacy.epsilons[len(m.privacy.epsilons)-1]
+}
+
+// progress notifies the printer and the callbacks of the progress of
+// training, and whether they stopped it
+type progress struct {
+	callbacks []Callback
+	metrics   Metrics
+	ts        time.Time
+	epoch     int
+	stop      bool
+}
+
+// begin notifies the printer and the callbacks that training of n, measured
+// on validation, begins
+func (o *options) begin(n *deep.Neural, validation Examples, printer *StatsPrinter) *progress {
+	p := &progress{
+		callbacks: append([]Callback{printer}, o.callbacks...),
+		metrics: Metrics{
+			Validation:  validation,
+			n:           n,
+			teacher:     printer.teacher,
+			regularizer: printer.regularizer,
+			clipping:    printer.clipping,
+			privacy:     printer.privacy,
+		},
+		ts: time.Now(),
+	}
+	for _, c := range p.callbacks {
+		c.OnTrainBegin(n, p.measure())
+	}
+	return p
+}
+
+// measure returns the metrics as of now
+func (p *progress) measure() *Metrics {
+	p.metrics.Elapsed = time.Since(p.ts)
+	return &p.metrics
+}
+
+func (p *progress) epochBegin(n *deep.Neural, epoch int) {
+	p.epoch = epoch
+	for _, c := range p.callbacks {
+		c.OnEpochBegin(n, epoch, p.measure())
+	}
+}
+
+// batchEnd reports whether a callback stopped training after the batch
+func (p *progress) batchEnd(n *deep.Neural, batch int) bool {
+	for _, c := range p.callbacks {
+		if c.OnBatchEnd(n, p.epoch, batch, p.measure()) {
+			p.stop = true
+		}
+	}
+	return p.stop
+}
+
+// epochEnd reports whether a callback stopped training after the epoch, or
+// did so during it
+func (p *progress) epochEnd(n *deep.Neural) bool {
+	for _, c := range p.callbacks {
+		if c.OnEpochEnd(n, p.epoch, p.measure()) {
+			p.stop = true
+		}
+	}
+	return p.stop
+}
+
+func (p *progress) trainEnd(n *deep.Neural) {
+	for _, c := range p.callbacks {
+		c.OnTrainEnd(n, p.epoch, p.measure())
+	}
+}
@@ -0,0 +1,110 @@
+package training
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the events of training, and stops it after the given
+// epoch or batch, if any
+type recorder struct {
+	BaseCallback
+	events     []string
+	stopEpoch  int
+	stopBatch  int
+	losses     []float64
+	epsilon    float64
+	validation int
+}
+
+func (r *recorder) OnTrainBegin(n *deep.Neural, m *Metrics) {
+	r.events = append(r.events, "begin")
+	r.validation = len(m.Validation)
+}
+
+func (r *recorder) OnEpochBegin(n *deep.Neural, epoch int, m *Metrics) {
+	r.events = append(r.events, fmt.Sprintf("epoch %d", epoch))
+}
+
+func (r *recorder) OnBatchEnd(n *deep.Neural, epoch, batch int, m *Metrics) bool {
+	r.events = append(r.events, fmt.Sprintf("batch %d", batch))
+	return batch == r.stopBatch
+}
+
+func (r *recorder) OnEpochEnd(n *deep.Neural, epoch int, m *Metrics) bool {
+	r.events = append(r.events, fmt.Sprintf("end %d", epoch))
+	r.losses = append(r.losses, m.Loss())
+	r.epsilon = m.Epsilon()
+	return epoch == r.stopEpoch
+}
+
+func (r *recorder) OnTrainEnd(n *deep.Neural, epoch int, m *Metrics) {
+	r.events = append(r.events, fmt.Sprintf("train end %d", epoch))
+}
+
+func Test_Callbacks(t *testing.T) {
+	train, test := blobs(40, 1), blobs(20, 2)
+	config := &deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+	var _ Callback = NewStatsPrinter()
+
+	for _, c := range []struct {
+		trainer func(opts ...Option) Trainer
+		batches int
+	}{
+		{func(opts ...Option) Trainer {
+			return NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 20, 2, append(opts, WithSeed(1))...)
+		}, 2},
+		{func(opts ...Option) Trainer {
+			return NewTrainer(NewAdam(0.01, 0, 0, 0), 0, append(opts, WithSeed(1))...)
+		}, 40},
+	} {
+		// Every event is notified in order, and metrics are of the validation
+		r := &recorder{}
+		n := deep.NewNeural(config)
+		c.trainer(WithCallbacks(r)).Train(n, append(Examples{}, train...), test, 2)
+		expected := []string{"begin"}
+		for epoch := 1; epoch <= 2; epoch++ {
+			expected = append(expected, fmt.Sprintf("epoch %d", epoch))
+			for batch := 1; batch <= c.batches; batch++ {
+				expected = append(expected, fmt.Sprintf("batch %d", batch))
+			}
+			expected = append(expected, fmt.Sprintf("end %d", epoch))
+		}
+		expected = append(expected, "train end 2")
+		assert.Equal(t, expected, r.events)
+		assert.Equal(t, len(test), r.validation)
+		assert.Equal(t, crossValidate(n, test), r.losses[1])
+		assert.True(t, math.IsNaN(r.epsilon))
+
+		// Measuring does not change training
+		measured, unmeasured := deep.NewNeural(config), deep.NewNeural(config)
+		c.trainer(WithCallbacks(&recorder{})).Train(measured, append(Examples{}, train...), test, 3)
+		c.trainer().Train(unmeasured, append(Examples{}, train...), test, 3)
+		assert.Equal(t, unmeasured.Weights(), measured.Weights())
+		assert.Equal(t, unmeasured.RecurrentState(), measured.RecurrentState())
+
+		// Stopped after an epoch, training is that of fewer epochs
+		stopped, fewer := deep.NewNeural(config), deep.NewNeural(config)
+		r = &recorder{stopEpoch: 3}
+		c.trainer(WithCallbacks(r)).Train(stopped, append(Examples{}, train...), nil, 10)
+		c.trainer().Train(fewer, append(Examples{}, train...), nil, 3)
+		assert.Equal(t, fewer.Weights(), stopped.Weights())
+		assert.Equal(t, "train end 3", r.events[len(r.events)-1])
+
+		// Stopped after a batch, the epoch still ends
+		r = &recorder{stopBatch: 1}
+		c.trainer(WithCallbacks(&recorder{}, r)).Train(deep.NewNeural(config), append(Examples{}, train...), nil, 10)
+		assert.Equal(t, []string{"begin", "epoch 1", "batch 1", "end 1", "train end 1"}, r.events)
+	}
+}
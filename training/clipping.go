@@ -0,0 +1,107 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Clipping limits the gradients of every update before they are passed to
+// the solver. Each limit is applied in turn, and is disabled when zero.
+type Clipping struct {
+	// Value clips every gradient to [-Value, Value]
+	Value float64
+	// LayerNorm rescales the gradients of every layer whose L2 norm exceeds it
+	LayerNorm float64
+	// GlobalNorm rescales all gradients when their L2 norm exceeds it
+	GlobalNorm float64
+}
+
+// clipping is the state of gradient clipping during training
+type clipping struct {
+	Clipping
+	clipped, updates int
+}
+
+// WithClipping makes the trainer clip gradients as configured by c
+func WithClipping(c Clipping) Option {
+	return func(o *options) { o.clipping = &clipping{Clipping: c} }
+}
+
+// Clipped returns the number of updates in which gradients were clipped,
+// along with the total number of updates
+func (o *options) Clipped() (clipped, updates int) {
+	if o.clipping == nil {
+		return 0, 0
+	}
+	return o.clipping.clipped, o.clipping.updates
+}
+
+// initClipping resets the counts of clipped updates as training begins
+func (o *options) initClipping() {
+	if o.clipping != nil {
+		o.clipping.clipped, o.clipping.updates = 0, 0
+	}
+}
+
+// clip clips the gradients of the weights of n in place, leaving out those
+// of pruned weights
+func (o *options) clip(n *deep.Neural, gradients [][][]float64) {
+	c := o.clipping
+	if c == nil {
+		return
+	}
+	clipped := false
+	each := func(i int, f func(g *float64)) {
+		for j, neuron := range n.Layers[i].Neurons {
+			for k, s := range neuron.In {
+				if !s.Pruned {
+					f(&gradients[i][j][k])
+				}
+			}
+		}
+	}
+	norm := func(i int) (sum float64) {
+		each(i, func(g *float64) { sum += *g * *g })
+		return
+	}
+	rescale := func(i int, factor float64) {
+		each(i, func(g *float64) { *g *= factor })
+	}
+
+	if c.Value > 0 {
+		for i := range n.Layers {
+			each(i, func(g *float64) {
+				if math.Abs(*g) > c.Value {
+					*g = math.Copysign(c.Value, *g)
+					clipped = true
+				}
+			})
+		}
+	}
+	if c.LayerNorm > 0 {
+		for i := range n.Layers {
+			if l := math.Sqrt(norm(i)); l > c.LayerNorm {
+				rescale(i, c.LayerNorm/l)
+				clipped = true
+			}
+		}
+	}
+	if c.GlobalNorm > 0 {
+		var sum float64
+		for i := range n.Layers {
+			sum += norm(i)
+		}
+		if g := math.Sqrt(sum); g > c.GlobalNorm {
+			for i := range n.Layers {
+				rescale(i, c.GlobalNorm/g)
+			}
+			clipped = true
+		}
+	}
+
+	if clipped {
+		c.clipped++
+	}
+	c.updates++
+}
@@ -0,0 +1,90 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Clipping(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 2,
+		Layout: []int{1, 1},
+		Bias:   false,
+		Seed:   1,
+	})
+	// Feed-forward and recurrent gradients of the hidden neuron, then of the output
+	gradients := func() [][][]float64 { return [][][]float64{{{3, -4, 0}}, {{12}}} }
+
+	o := newOptions([]Option{WithClipping(Clipping{Value: 2})})
+	g := gradients()
+	o.clip(n, g)
+	assert.Equal(t, [][][]float64{{{2, -2, 0}}, {{2}}}, g)
+
+	o = newOptions([]Option{WithClipping(Clipping{LayerNorm: 1})})
+	g = gradients()
+	o.clip(n, g)
+	assert.InDeltaSlice(t, []float64{0.6, -0.8, 0}, g[0][0], 1e-12)
+	assert.InDeltaSlice(t, []float64{1}, g[1][0], 1e-12)
+
+	o = newOptions([]Option{WithClipping(Clipping{GlobalNorm: 6.5})})
+	g = gradients()
+	o.clip(n, g)
+	assert.InDeltaSlice(t, []float64{1.5, -2, 0}, g[0][0], 1e-12)
+	assert.InDeltaSlice(t, []float64{6}, g[1][0], 1e-12)
+
+	// Pruned weights are left out of the norms
+	n.Layers[1].Neurons[0].In[0].Prune()
+	g = gradients()
+	o.clip(n, g)
+	assert.Equal(t, gradients(), g)
+
+	clipped, updates := o.Clipped()
+	assert.Equal(t, 1, clipped)
+	assert.Equal(t, 2, updates)
+}
+
+func Test_ClippedTraining(t *testing.T) {
+	train := func(c Clipping, batch bool) (int, int, [][][]float64) {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{3, 1},
+			Activation: deep.ActivationSigmoid,
+			Bias:       true,
+			Seed:       42,
+		})
+		var clipped, updates int
+		if batch {
+			trainer := NewBatchTrainer(NewSGD(0.5, 0.1, 0, false), 0, 2, 1, WithSeed(1), WithClipping(c))
+			trainer.Train(n, append(Examples{}, data...), nil, 20)
+			clipped, updates = trainer.Clipped()
+		} else {
+			trainer := NewTrainer(NewSGD(0.5, 0.1, 0, false), 0, WithSeed(1), WithClipping(c))
+			trainer.Train(n, append(Examples{}, data...), nil, 20)
+			clipped, updates = trainer.Clipped()
+		}
+		return clipped, updates, n.Weights()
+	}
+
+	for _, batch := range []bool{false, true} {
+		clipped, updates, _ := train(Clipping{GlobalNorm: 1e-6}, batch)
+		assert.Equal(t, updates, clipped)
+		assert.True(t, updates > 0)
+
+		clipped, _, weights := train(Clipping{Value: math.MaxFloat64}, batch)
+		assert.Equal(t, 0, clipped)
+		_, _, reference := train(Clipping{}, batch)
+		assert.Equal(t, reference, weights)
+	}
+
+	// Counts start over every time training begins
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{3, 1}, Activation: deep.ActivationSigmoid, Bias: true, Seed: 42})
+	trainer := NewTrainer(NewSGD(0.5, 0.1, 0, false), 0, WithSeed(1), WithClipping(Clipping{GlobalNorm: 1e-6}))
+	trainer.Train(n, append(Examples{}, data...), nil, 5)
+	_, first := trainer.Clipped()
+	trainer.Train(n, append(Examples{}, data...), nil, 5)
+	_, second := trainer.Clipped()
+	assert.Equal(t, first, second)
+}
@@ -0,0 +1,82 @@
+package training
+
+import (
+	"fmt"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// LowRank compresses a dense layer of a network into two thinner layers by
+// truncated singular value decomposition of its weights
+type LowRank struct {
+	// Layer to factorize
+	Layer int
+	// Rank of the factorization. When zero, the smallest rank retaining the
+	// Energy fraction of the sum of squared singular values is used, which
+	// must then be in (0, 1].
+	Rank   int
+	Energy float64
+	// Trainer, when set, fine-tunes the compressed network on Examples for
+	// Iterations epochs
+	Trainer    Trainer
+	Examples   Examples
+	Iterations int
+}
+
+// Compression reports the size and validation performance of a network
+// before and after compression. Accuracy is the fraction of examples whose
+// largest output matches the largest response.
+type Compression struct {
+	Rank                         int
+	Weights, CompressedWeights   int
+	Loss, CompressedLoss         float64
+	Accuracy, CompressedAccuracy float64
+}
+
+// Savings is the fraction of weights removed by compression
+func (c Compression) Savings() float64 {
+	return 1 - float64(c.CompressedWeights)/float64(c.Weights)
+}
+
+// Compress returns a compressed copy of n and how it compares to n on the
+// validation examples
+func (c LowRank) Compress(n *deep.Neural, validation Examples) (*deep.Neural, Compression, error) {
+	rank := c.Rank
+	if rank == 0 {
+		if c.Energy <= 0 || c.Energy > 1 {
+			return nil, Compression{}, fmt.Errorf("Invalid energy %v - either a rank or an energy in (0, 1] is required", c.Energy)
+		}
+		rank = deep.EnergyRank(deep.SingularValues(n, c.Layer), c.Energy)
+	}
+	compressed, err := deep.Factorize(n, c.Layer, rank)
+	if err != nil {
+		return nil, Compression{}, err
+	}
+	if c.Trainer != nil {
+		c.Trainer.Train(compressed, c.Examples, validation, c.Iterations)
+	}
+
+	return compressed, Compression{
+		Rank:               rank,
+		Weights:            activeWeights(n),
+		CompressedWeights:  activeWeights(compressed),
+		Loss:               crossValidate(n, validation),
+		CompressedLoss:     crossValidate(compressed, validation),
+		Accuracy:           accuracy(n, validation),
+		CompressedAccuracy: accuracy(compressed, validation),
+	}, nil
+}
+
+// activeWeights is the number of weights of n which are not pruned
+func activeWeights(n *deep.Neural) (num int) {
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if !s.Pruned {
+					num++
+				}
+			}
+		}
+	}
+	return
+}
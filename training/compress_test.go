@@ -0,0 +1,50 @@
+package training
+
+import (
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LowRank(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+	train, validation := data.SplitWith(0.7, deep.NewRand(1))
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     len(data[0].Input),
+		Layout:     []int{32, 3},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Init:       &deep.Initializer{Kind: "glorot_uniform"},
+		Bias:       true,
+		Seed:       1,
+	})
+	NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1)).Train(n, train, nil, 50)
+
+	compressed, c, err := LowRank{
+		Layer:      0,
+		Rank:       4,
+		Trainer:    NewBatchTrainer(NewAdam(0.001, 0, 0, 0), 0, 16, 2, WithSeed(1)),
+		Examples:   train,
+		Iterations: 10,
+	}.Compress(n, validation)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, c.Rank)
+	assert.Equal(t, n.NumWeights(), c.Weights)
+	assert.Equal(t, compressed.NumWeights()-2*4, c.CompressedWeights)
+	assert.True(t, c.Savings() > 0.4, "savings %f", c.Savings())
+	assert.True(t, c.Accuracy > 0.9)
+	assert.True(t, c.CompressedAccuracy > c.Accuracy-0.05, "accuracy %f -> %f", c.Accuracy, c.CompressedAccuracy)
+
+	_, c, err = LowRank{Layer: 0, Energy: 0.99}.Compress(n, validation)
+	assert.NoError(t, err)
+	assert.True(t, c.Rank > 4 && c.Rank <= len(data[0].Input))
+
+	_, _, err = LowRank{Layer: 0, Rank: 20}.Compress(n, validation)
+	assert.Error(t, err)
+	_, _, err = LowRank{Layer: 0}.Compress(n, validation)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Constraint restricts the incoming weights of a neuron, leaving out its bias
+// and pruned weights, after every update
+type Constraint interface {
+	Constrain(in []*deep.Synapse)
+}
+
+// MaxNorm rescales incoming weights whose L2 norm exceeds its value
+type MaxNorm float64
+
+// Constrain rescales in to a norm of at most c
+func (c MaxNorm) Constrain(in []*deep.Synapse) {
+	if norm := l2(in); norm > float64(c) {
+		scale(in, float64(c)/norm)
+	}
+}
+
+// UnitNorm rescales incoming weights to an L2 norm of one
+type UnitNorm struct{}
+
+// Constrain rescales in to unit norm
+func (c UnitNorm) Constrain(in []*deep.Synapse) {
+	if norm := l2(in); norm > 0 {
+		scale(in, 1/norm)
+	}
+}
+
+// NonNegative clips negative weights to zero, which makes networks with
+// monotonic activations non-decreasing in their inputs
+type NonNegative struct{}
+
+// Constrain clips the negative weights of in
+func (c NonNegative) Constrain(in []*deep.Synapse) {
+	for _, s := range in {
+		s.Weight = math.Max(s.Weight, 0)
+	}
+}
+
+func l2(in []*deep.Synapse) float64 {
+	var sum float64
+	for _, s := range in {
+		sum += s.Weight * s.Weight
+	}
+	return math.Sqrt(sum)
+}
+
+func scale(in []*deep.Synapse, factor float64) {
+	for _, s := range in {
+		s.Weight *= factor
+	}
+}
+
+// WithConstraint makes the trainer apply the constraints, in order, to the
+// neurons of layer i after every update
+func WithConstraint(i int, constraints ...Constraint) Option {
+	return func(o *options) {
+		if o.constraints == nil {
+			o.constraints = make(map[int][]Constraint)
+		}
+		o.constraints[i] = append(o.constraints[i], constraints...)
+	}
+}
+
+// constrain applies the constraints of every layer of n
+func (o *options) constrain(n *deep.Neural) {
+	for i, constraints := range o.constraints {
+		for _, neuron := range n.Layers[i].Neurons {
+			in := make([]*deep.Synapse, 0, len(neuron.In))
+			for _, s := range neuron.In {
+				if !s.IsBias && !s.Pruned {
+					in = append(in, s)
+				}
+			}
+			for _, c := range constraints {
+				c.Constrain(in)
+			}
+		}
+	}
+}
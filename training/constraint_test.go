@@ -0,0 +1,108 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func synapses(weights ...float64) []*deep.Synapse {
+	in := make([]*deep.Synapse, len(weights))
+	for i, w := range weights {
+		in[i] = deep.NewSynapse(w)
+	}
+	return in
+}
+
+func Test_Constraints(t *testing.T) {
+	in := synapses(3, -4)
+	MaxNorm(2).Constrain(in)
+	assert.InDelta(t, 1.2, in[0].Weight, 1e-12)
+	assert.InDelta(t, -1.6, in[1].Weight, 1e-12)
+	MaxNorm(5).Constrain(in)
+	assert.InDelta(t, 1.2, in[0].Weight, 1e-12)
+
+	in = synapses(0.3, -0.4)
+	UnitNorm{}.Constrain(in)
+	assert.InDelta(t, 0.6, in[0].Weight, 1e-12)
+	assert.InDelta(t, -0.8, in[1].Weight, 1e-12)
+
+	in = synapses(0.3, -0.4)
+	NonNegative{}.Constrain(in)
+	assert.Equal(t, []float64{0.3, 0}, []float64{in[0].Weight, in[1].Weight})
+}
+
+func Test_MonotoneRegression(t *testing.T) {
+	var examples Examples
+	for i := 0; i < 50; i++ {
+		x := float64(i) / 50
+		examples = append(examples, Example{
+			Input:    []float64{x, 1 - x},
+			Response: []float64{x*x + 0.1*math.Sin(20*x)},
+		})
+	}
+
+	for _, trainer := range []Trainer{
+		NewTrainer(NewAdam(0.01, 0, 0, 0), 0, WithSeed(1),
+			WithConstraint(0, NonNegative{}), WithConstraint(1, NonNegative{}, MaxNorm(3))),
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 8, 2, WithSeed(1),
+			WithConstraint(0, NonNegative{}), WithConstraint(1, NonNegative{}, MaxNorm(3))),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{6, 1},
+			Activation: deep.ActivationSigmoid,
+			Mode:       deep.ModeRegression,
+			Init:       &deep.Initializer{Kind: "normal", Std: 1},
+			Bias:       true,
+			Seed:       1,
+		})
+		trainer.Train(n, examples, nil, 100)
+
+		for _, l := range n.Layers {
+			for _, neuron := range l.Neurons {
+				for _, s := range neuron.In {
+					if !s.IsBias {
+						assert.True(t, s.Weight >= 0)
+					}
+				}
+			}
+		}
+		var norm float64
+		for _, s := range n.Layers[1].Neurons[0].In {
+			norm += s.Weight * s.Weight
+		}
+		assert.True(t, math.Sqrt(norm) <= 3+1e-9)
+
+		// Fresh copies start from the same recurrent state
+		prev := math.Inf(-1)
+		for x := 0.0; x <= 1; x += 0.05 {
+			y := deep.FromDump(n.Dump()).Predict([]float64{x, 0.5})[0]
+			assert.True(t, y >= prev)
+			prev = y
+		}
+	}
+}
+
+func Test_UnitNorm(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 2,
+		Layout: []int{4, 1},
+		Bias:   true,
+		Seed:   1,
+	})
+	NewTrainer(NewSGD(0.5, 0, 0, false), 0, WithSeed(1), WithConstraint(0, UnitNorm{})).
+		Train(n, append(Examples{}, data...), nil, 5)
+
+	for _, neuron := range n.Layers[0].Neurons {
+		var norm float64
+		for _, s := range neuron.In {
+			if !s.IsBias {
+				norm += s.Weight * s.Weight
+			}
+		}
+		assert.InDelta(t, 1, norm, 1e-9)
+	}
+}
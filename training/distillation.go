@@ -0,0 +1,123 @@
+package training
+
+import (
+	"fmt"
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// distillation trains a student network to mimic a teacher network
+type distillation struct {
+	teacher     *deep.Neural
+	temperature float64
+	alpha       float64
+}
+
+// WithDistillation makes the trainer blend the loss on the responses of the
+// examples with a loss on the outputs of teacher, weighing the latter by
+// alpha. Outputs of classifiers are softened by the temperature, and the
+// student is trained on the KL divergence of its softened outputs from the
+// teacher's, scaled by the squared temperature (Hinton et al., 2015).
+// Outputs of other networks are regressed on those of the teacher.
+func WithDistillation(teacher *deep.Neural, temperature, alpha float64) Option {
+	return func(o *options) {
+		o.distillation = &distillation{
+			teacher:     teacher,
+			temperature: fparam(temperature, 1),
+			alpha:       alpha,
+		}
+	}
+}
+
+// teach returns a copy of examples labelled with the outputs of the teacher
+func (o *options) teach(n *deep.Neural, examples Examples) Examples {
+	if o.distillation == nil {
+		return examples
+	}
+	if _, ok := deep.GetLoss(n.Config.Loss).(deep.BatchLoss); ok {
+		panic(fmt.Sprintf("Distillation is not supported with %s loss", n.Config.Loss))
+	}
+	taught := make(Examples, len(examples))
+	for i, e := range examples {
+		taught[i] = e
+		taught[i].teacher = o.distillation.teacher.Predict(e.Input)
+	}
+	return taught
+}
+
+// distill blends the output deltas of n for e with the gradient of the
+// distillation loss with respect to the output pre-activations
+func (o *options) distill(n *deep.Neural, e Example, deltas []float64) {
+	if o.distillation == nil {
+		return
+	}
+	d := o.distillation
+	out := n.Layers[len(n.Layers)-1].Neurons
+	student := make([]float64, len(out))
+	for i, neuron := range out {
+		student[i] = neuron.Value
+	}
+
+	var soft []float64
+	switch n.Config.Mode {
+	case deep.ModeMultiClass:
+		p, q := soften(student, d.temperature), soften(e.teacher, d.temperature)
+		soft = make([]float64, len(p))
+		for i := range soft {
+			soft[i] = d.temperature * (p[i] - q[i])
+		}
+	case deep.ModeBinary, deep.ModeMultiLabel:
+		soft = make([]float64, len(student))
+		for i := range soft {
+			soft[i] = d.temperature * (softenBinary(student[i], d.temperature) - softenBinary(e.teacher[i], d.temperature))
+		}
+	default:
+		loss := deep.GetLoss(n.Config.Loss)
+		soft = make([]float64, len(student))
+		for i, neuron := range out {
+			soft[i] = loss.Df(neuron.Value, e.teacher[i], neuron.DActivate(neuron.Value))
+		}
+	}
+
+	for i := range deltas[:len(out)] {
+		deltas[i] = (1-d.alpha)*deltas[i] + d.alpha*e.EffectiveWeight()*soft[i]
+	}
+}
+
+// soften returns the softmax of logits recovered from the probabilities p,
+// divided by the temperature
+func soften(p []float64, temperature float64) []float64 {
+	logits := make([]float64, len(p))
+	for i := range p {
+		logits[i] = math.Log(math.Max(p[i], 1e-16)) / temperature
+	}
+	return deep.Softmax(logits)
+}
+
+// softenBinary returns the logistic of the logit of p divided by the temperature
+func softenBinary(p, temperature float64) float64 {
+	p = math.Min(math.Max(p, 1e-16), 1-1e-16)
+	return deep.Logistic(math.Log(p/(1-p))/temperature, 1)
+}
+
+// agreement is the fraction of examples on which n and teacher predict the
+// same class, or for multi-label networks, the same labels
+func agreement(n, teacher *deep.Neural, validation Examples) float64 {
+	agree := 0
+	for _, e := range validation {
+		student, reference := n.Predict(e.Input), teacher.Predict(e.Input)
+		same := true
+		if n.Config.Mode == deep.ModeMultiClass {
+			same = deep.ArgMax(student) == deep.ArgMax(reference)
+		} else {
+			for i := range student {
+				same = same && (student[i] > 0.5) == (reference[i] > 0.5)
+			}
+		}
+		if same {
+			agree++
+		}
+	}
+	return float64(agree) / float64(len(validation))
+}
@@ -0,0 +1,82 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DistillationGradient(t *testing.T) {
+	logits, teacher := []float64{0.3, -1.2, 2.0}, []float64{0.1, 0.2, 0.7}
+	temperature := 3.0
+
+	// T²·KL(q‖p) between the softened teacher and student distributions
+	loss := func(z []float64) float64 {
+		p, q := soften(deep.Softmax(z), temperature), soften(teacher, temperature)
+		var kl float64
+		for i := range p {
+			kl += q[i] * math.Log(q[i]/p[i])
+		}
+		return temperature * temperature * kl
+	}
+
+	p, q := soften(deep.Softmax(logits), temperature), soften(teacher, temperature)
+	for i := range logits {
+		h := 1e-6
+		plus, minus := append([]float64{}, logits...), append([]float64{}, logits...)
+		plus[i] += h
+		minus[i] -= h
+		numeric := (loss(plus) - loss(minus)) / (2 * h)
+		assert.InDelta(t, numeric, temperature*(p[i]-q[i]), 1e-6)
+	}
+
+	assert.InDelta(t, deep.Logistic(0.8/2, 1), softenBinary(deep.Logistic(0.8, 1), 2), 1e-12)
+}
+
+func Test_Distillation(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+	train, validation := data.SplitWith(0.7, deep.NewRand(1))
+
+	teacher := deep.NewNeural(&deep.Config{
+		Inputs:     len(data[0].Input),
+		Layout:     []int{32, 3},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Init:       &deep.Initializer{Kind: "glorot_uniform"},
+		Bias:       true,
+		Seed:       1,
+	})
+	NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1)).Train(teacher, train, nil, 50)
+
+	for _, trainer := range []Trainer{
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1), WithDistillation(teacher, 4, 0.7)),
+		NewTrainer(NewAdam(0.005, 0, 0, 0), 0, WithSeed(1), WithDistillation(teacher, 4, 0.7)),
+	} {
+		student := deep.NewNeural(&deep.Config{
+			Inputs:     len(data[0].Input),
+			Layout:     []int{3, 3},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Init:       &deep.Initializer{Kind: "glorot_uniform"},
+			Bias:       true,
+			Seed:       2,
+		})
+		trainer.Train(student, train, nil, 50)
+
+		assert.True(t, accuracy(student, validation) > 0.9)
+		assert.True(t, agreement(student, teacher, validation) > 0.85)
+	}
+
+	// Callers' examples are not labelled with the outputs of the teacher
+	for _, e := range train {
+		assert.Nil(t, e.teacher)
+	}
+
+	cox := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{1}, Mode: deep.ModeSurvival})
+	assert.Panics(t, func() {
+		NewTrainer(NewSGD(0.1, 0, 0, false), 0, WithDistillation(cox, 1, 0.5)).Train(cox, train[:1], nil, 1)
+	})
+}
@@ -0,0 +1,374 @@
+package training
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// push carries the gradients of a batch from a worker to the parameter
+// server, or none to only pull the weights. The first push of a worker
+// carries the shape of its network instead, which must match that of the
+// server.
+type push struct {
+	Gradients [][][]float64
+	Examples  int
+	Epoch     int
+	Done      bool
+	Shape     [][]int
+}
+
+// pull carries the weights from the parameter server to a worker, or why
+// they cannot be exchanged
+type pull struct {
+	Weights [][][]float64
+	Error   string
+}
+
+// shape returns the number of weights feeding every neuron of n
+func shape(n *deep.Neural) [][]int {
+	s := make([][]int, len(n.Layers))
+	for i, l := range n.Layers {
+		s[i] = make([]int, len(l.Neurons))
+		for j, neuron := range l.Neurons {
+			s[i][j] = len(neuron.In)
+		}
+	}
+	return s
+}
+
+// sameShape reports whether shapes a and b are equal
+func sameShape(a, b [][]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParameterServer implements data-parallel training by workers, possibly in
+// other processes, which compute the gradients of batches of their shard of
+// the examples and send them over TCP. The server updates the weights with
+// the gradients and sends them back.
+//
+// With a staleness of zero training is synchronous: the gradients of a batch
+// of every worker are summed, as for one batch of all their examples, before
+// the weights are updated. Otherwise the weights are updated with every
+// batch as soon as it arrives, and a worker waits for the weights only when it
+// is more than staleness batches ahead of the slowest worker (stale
+// synchronous parallel, Ho et al., 2013).
+type ParameterServer struct {
+	*BatchTrainer
+	workers   int
+	staleness int
+
+	n          *deep.Neural
+	validation Examples
+	ts         time.Time
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	active  []bool
+	epochs  []int
+	clocks  []int
+	arrived int
+	step    int
+	epoch   int
+	// examples whose gradients have been accumulated since the last update
+	examples int
+}
+
+// NewParameterServer returns a ParameterServer for the given number of
+// workers, which may run at most staleness batches ahead of each other. The
+// server applies the options acting on updates and epochs, pruning,
+// constraints, clipping and proximal terms, and panics on the others, which
+// belong to the workers or are not supported.
+func NewParameterServer(solver Solver, verbosity, workers, staleness int, opts ...Option) *ParameterServer {
+	o := newOptions(opts)
+	o.supportOnly("a parameter server", "WithPruning", "WithConstraint", "WithClipping", "WithProximal")
+	return &ParameterServer{
+		BatchTrainer: NewBatchTrainer(solver, verbosity, 1, 1, opts...),
+		workers:      iparam(workers, 1),
+		staleness:    staleness,
+	}
+}
+
+// Serve trains n with the workers connecting to l, printing progress on
+// validation, until all of them are done
+func (s *ParameterServer) Serve(l net.Listener, n *deep.Neural, validation Examples) error {
+	s.internalb = newBatchTraining(n.Layers, 1)
+	s.printer.Init(n)
+	s.solver.Init(n.NumWeights())
+	initRegularization(s.solver, n)
+	s.printer.regularizer = regularizerOf(s.solver)
+	s.initClipping()
+	s.printer.clipping = s.clipping
+
+	s.n, s.validation, s.ts = n, validation, time.Now()
+	s.cond = sync.NewCond(&s.mu)
+	s.active = make([]bool, s.workers)
+	s.epochs = make([]int, s.workers)
+	s.clocks = make([]int, s.workers)
+	for w := range s.active {
+		s.active[w], s.epochs[w] = true, 1
+	}
+	s.arrived, s.step, s.epoch, s.examples = 0, 0, 1, 0
+
+	errs := make([]error, s.workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < s.workers; w++ {
+		conn, err := l.Accept()
+		if err != nil {
+			// Workers which never connect are done
+			for ; w < s.workers; w++ {
+				s.leave(w)
+			}
+			wg.Wait()
+			return err
+		}
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs[w] = s.handle(conn, w)
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle exchanges gradients for weights with the w-th worker
+func (s *ParameterServer) handle(conn net.Conn, w int) error {
+	defer conn.Close()
+	defer s.leave(w)
+
+	dec, enc := gob.NewDecoder(conn), gob.NewEncoder(conn)
+	for first := true; ; first = false {
+		var p push
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+		if p.Done {
+			return nil
+		}
+		if first && !sameShape(p.Shape, shape(s.n)) {
+			err := fmt.Errorf("Invalid worker network - expected shape: %v got: %v", shape(s.n), p.Shape)
+			enc.Encode(pull{Error: err.Error()})
+			return err
+		}
+		if p.Gradients != nil {
+			s.push(w, p)
+		}
+
+		s.mu.Lock()
+		weights := s.n.Weights()
+		s.mu.Unlock()
+		if err := enc.Encode(pull{Weights: weights}); err != nil {
+			return err
+		}
+	}
+}
+
+// push updates the weights with the gradients of the w-th worker, waiting
+// for those of the others when training is synchronous, or for the slowest
+// worker to catch up when w is too far ahead
+func (s *ParameterServer) push(w int, p push) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epochs[w] = p.Epoch
+	s.advance()
+
+	for i, l := range p.Gradients {
+		for j, neuron := range l {
+			for k, g := range neuron {
+				s.accumulatedDeltas[i][j][k] += g
+			}
+		}
+	}
+	s.examples += p.Examples
+
+	if s.staleness == 0 {
+		s.arrived++
+		step := s.step
+		s.synchronize()
+		for s.step == step {
+			s.cond.Wait()
+		}
+		return
+	}
+
+	s.apply()
+	s.clocks[w]++
+	s.cond.Broadcast()
+	for s.clocks[w]-s.slowest() > s.staleness {
+		s.cond.Wait()
+	}
+}
+
+// leave marks the w-th worker done, so that the others no longer wait for it
+func (s *ParameterServer) leave(w int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active[w] {
+		return
+	}
+	s.active[w] = false
+	if s.staleness == 0 {
+		s.synchronize()
+	}
+	s.advance()
+	s.cond.Broadcast()
+}
+
+// synchronize updates the weights once the gradients of every active worker
+// have arrived
+func (s *ParameterServer) synchronize() {
+	var active int
+	for _, a := range s.active {
+		if a {
+			active++
+		}
+	}
+	if s.arrived == 0 || s.arrived < active {
+		return
+	}
+	s.apply()
+	s.arrived = 0
+	s.step++
+	s.cond.Broadcast()
+}
+
+// apply updates the weights with the accumulated gradients
+func (s *ParameterServer) apply() {
+	s.approach(s.n, s.accumulatedDeltas, s.examples)
+	regularizeExamples(s.solver, s.examples)
+	s.update(s.n, s.epoch)
+	s.examples = 0
+}
+
+// slowest returns the number of batches of the slowest active worker
+func (s *ParameterServer) slowest() int {
+	slowest := -1
+	for w, a := range s.active {
+		if a && (slowest < 0 || s.clocks[w] < slowest) {
+			slowest = s.clocks[w]
+		}
+	}
+	return slowest
+}
+
+// advance ends the epochs which every active worker is done with, or all of
+// them when the workers are
+func (s *ParameterServer) advance() {
+	epoch := -1
+	for w, a := range s.active {
+		if a && (epoch < 0 || s.epochs[w] < epoch) {
+			epoch = s.epochs[w]
+		}
+	}
+	if epoch < 0 {
+		epoch = s.epoch + 1
+	}
+	for ; s.epoch < epoch; s.epoch++ {
+		s.prune(s.n, s.epoch)
+		if s.verbosity > 0 && s.epoch%s.verbosity == 0 && len(s.validation) > 0 {
+			s.printer.PrintProgress(s.n, s.validation, time.Since(s.ts), s.epoch)
+		}
+	}
+}
+
+// Worker trains with a ParameterServer on a shard of the examples
+type Worker struct {
+	*BatchTrainer
+}
+
+// NewWorker returns a Worker computing the gradients of batches of
+// batchSize examples with parallelism goroutines. The worker applies the
+// options acting on gradients, fake quantization, distillation and
+// adversarial training, and panics on the others, which belong to the
+// parameter server or are not supported.
+func NewWorker(batchSize, parallelism int, opts ...Option) *Worker {
+	o := newOptions(opts)
+	o.supportOnly("a worker", "WithFakeQuantization", "WithDistillation", "WithAdversarialTraining")
+	return &Worker{BatchTrainer: NewBatchTrainer(nil, 0, batchSize, parallelism, opts...)}
+}
+
+// Train trains a network configured by c on examples for iterations, with
+// the parameter server at address
+func (w *Worker) Train(address string, c *deep.Config, examples Examples, iterations int) error {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	n := deep.NewNeural(c)
+	enc, dec := gob.NewEncoder(conn), gob.NewDecoder(conn)
+	exchange := func(p push) error {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+		var r pull
+		if err := dec.Decode(&r); err != nil {
+			return err
+		}
+		if r.Error != "" {
+			return errors.New(r.Error)
+		}
+		n.ApplyWeights(r.Weights)
+		return nil
+	}
+
+	w.internalb = newBatchTraining(n.Layers, w.parallelism)
+
+	train := make(Examples, len(examples))
+	copy(train, w.teach(n, examples))
+
+	nets := make([]*deep.Neural, w.parallelism)
+	for i := range nets {
+		nets[i] = deep.NewNeural(c)
+	}
+
+	if err := exchange(push{Epoch: 1, Shape: shape(n)}); err != nil {
+		return err
+	}
+	for it := 1; it <= iterations; it++ {
+		train.ShuffleWith(w.rand)
+		for _, b := range train.SplitSize(w.batchSize) {
+			b = w.perturb(n, b)
+			w.accumulate(n, nets, b)
+			if err := exchange(push{Gradients: w.accumulatedDeltas, Examples: len(b), Epoch: it}); err != nil {
+				return err
+			}
+			for _, l := range w.accumulatedDeltas {
+				for _, neuron := range l {
+					for k := range neuron {
+						neuron[k] = 0
+					}
+				}
+			}
+		}
+	}
+	return enc.Encode(push{Done: true})
+}
@@ -0,0 +1,100 @@
+package training
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+var distributed = &deep.Config{
+	Inputs:     2,
+	Layout:     []int{8, 2},
+	Activation: deep.ActivationTanh,
+	Mode:       deep.ModeMultiClass,
+	Bias:       true,
+	Seed:       1,
+}
+
+func Test_ParameterServer(t *testing.T) {
+	train := blobs(200, 1)
+
+	// A single synchronous worker trains as a batch trainer does
+	batch := deep.NewNeural(distributed)
+	NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 20, 2, WithSeed(1)).Train(batch, train, nil, 5)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	errs := make(chan error)
+	go func() { errs <- NewWorker(20, 2, WithSeed(1)).Train(l.Addr().String(), distributed, train, 5) }()
+
+	n := deep.NewNeural(distributed)
+	assert.NoError(t, NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, 1, 0).Serve(l, n, nil))
+	assert.NoError(t, <-errs)
+	assert.Equal(t, batch.Weights(), n.Weights())
+
+	// A worker whose network differs is turned away
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	other := *distributed
+	other.Layout = []int{4, 2}
+	go func() { errs <- NewWorker(20, 2, WithSeed(1)).Train(l.Addr().String(), &other, train, 5) }()
+	assert.Error(t, NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, 1, 0).Serve(l, n, nil))
+	assert.Error(t, <-errs)
+
+	// Options are applied by one side, and not supported by the other
+	assert.Panics(t, func() { NewWorker(20, 2, WithPrivacy(Privacy{Clip: 1})) })
+	assert.Panics(t, func() { NewWorker(20, 2, WithClipping(Clipping{Value: 1})) })
+	assert.Panics(t, func() { NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, 1, 0, WithPrivacy(Privacy{Clip: 1})) })
+	assert.Panics(t, func() { NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, 1, 0, WithDistillation(n, 2, 0.5)) })
+	assert.NotPanics(t, func() { NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, 1, 0, WithClipping(Clipping{Value: 1})) })
+}
+
+// Test_Worker is a worker of Test_Distributed in a process of its own
+func Test_Worker(t *testing.T) {
+	address := os.Getenv("DEEP_PARAMETER_SERVER")
+	if address == "" {
+		t.Skip("run by Test_Distributed")
+	}
+	shard, err := strconv.Atoi(os.Getenv("DEEP_SHARD"))
+	assert.NoError(t, err)
+
+	// Shards differ in size, so that workers are done at different times
+	examples := blobs(600, 1)[:100*(shard+1)]
+	assert.NoError(t, NewWorker(10, 1, WithSeed(int64(shard))).Train(address, distributed, examples, 10))
+}
+
+func Test_Distributed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts processes")
+	}
+	test := blobs(200, 2)
+	for _, staleness := range []int{0, 2} {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		var workers []*exec.Cmd
+		for shard := 0; shard < 3; shard++ {
+			cmd := exec.Command(os.Args[0], "-test.run=^Test_Worker$")
+			cmd.Env = append(os.Environ(),
+				"DEEP_PARAMETER_SERVER="+l.Addr().String(),
+				"DEEP_SHARD="+strconv.Itoa(shard))
+			assert.NoError(t, cmd.Start())
+			workers = append(workers, cmd)
+		}
+
+		n := deep.NewNeural(distributed)
+		assert.NoError(t, NewParameterServer(NewAdam(0.01, 0, 0, 0), 0, len(workers), staleness).Serve(l, n, test))
+		for _, cmd := range workers {
+			assert.NoError(t, cmd.Wait())
+		}
+		l.Close()
+		assert.True(t, accuracy(n, test) > 0.9, "staleness %d", staleness)
+	}
+}
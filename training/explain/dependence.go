@@ -0,0 +1,58 @@
+package explain
+
+import deep "github.com/Maxime2/go-deep"
+
+// Curve describes how an output of the predictions depends on an input
+// feature, as the feature is set to every value of a grid
+type Curve struct {
+	Feature string
+	Grid    []float64
+	// ICE holds the individual conditional expectation curve of every
+	// validation example: its output with the feature set to each grid value
+	ICE [][]float64
+	// PD is the partial dependence, the mean of the ICE curves
+	PD []float64
+}
+
+// Grid returns points values of feature evenly spaced from its smallest to
+// its largest value over the validation examples
+func (e *Explainer) Grid(feature, points int) []float64 {
+	values := make([]float64, len(e.validation.Examples))
+	for i, ex := range e.validation.Examples {
+		values[i] = ex.Input[feature]
+	}
+	lo, hi := deep.Min(values), deep.Max(values)
+
+	grid := make([]float64, points)
+	for g := range grid {
+		grid[g] = lo
+		if points > 1 {
+			grid[g] += (hi - lo) * float64(g) / float64(points-1)
+		}
+	}
+	return grid
+}
+
+// PartialDependence returns the partial dependence and ICE curves of output
+// on feature over grid
+func (e *Explainer) PartialDependence(feature, output int, grid []float64) Curve {
+	defer e.restore()
+
+	examples := e.validation.Examples
+	curve := Curve{
+		Feature: e.validation.Feature(feature),
+		Grid:    grid,
+		ICE:     make([][]float64, len(examples)),
+		PD:      make([]float64, len(grid)),
+	}
+	for i, ex := range examples {
+		input := append([]float64{}, ex.Input...)
+		curve.ICE[i] = make([]float64, len(grid))
+		for g, v := range grid {
+			input[feature] = v
+			curve.ICE[i][g] = e.predict(input)[output]
+			curve.PD[g] += curve.ICE[i][g] / float64(len(examples))
+		}
+	}
+	return curve
+}
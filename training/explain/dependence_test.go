@@ -0,0 +1,34 @@
+package explain
+
+import (
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PartialDependence(t *testing.T) {
+	n, d := linear(2, -1)
+	e := New(n, d, nil)
+
+	grid := e.Grid(1, 5)
+	assert.Len(t, grid, 5)
+	values := make([]float64, len(d.Examples))
+	for i, ex := range d.Examples {
+		values[i] = ex.Input[1]
+	}
+	assert.Equal(t, deep.Min(values), grid[0])
+	assert.InDelta(t, deep.Max(values), grid[4], 1e-12)
+
+	curve := e.PartialDependence(1, 0, grid)
+	assert.Equal(t, "x1", curve.Feature)
+	assert.Len(t, curve.ICE, len(d.Examples))
+	for g := range grid {
+		var mean float64
+		for i, ex := range d.Examples {
+			assert.InDelta(t, 2*ex.Input[0]-grid[g], curve.ICE[i][g], 1e-12)
+			mean += curve.ICE[i][g] / float64(len(d.Examples))
+		}
+		assert.InDelta(t, mean, curve.PD[g], 1e-12)
+	}
+}
@@ -0,0 +1,77 @@
+// Package explain explains the predictions of trained networks without their
+// gradients, from how the predictions respond to changes of the inputs.
+package explain
+
+import (
+	"math/rand"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/Maxime2/go-deep/training"
+)
+
+// Explainer explains the predictions of a network on a validation dataset.
+// Every prediction is made from the recurrent state the network was in when
+// the explainer was created, which it is left in.
+type Explainer struct {
+	n          *deep.Neural
+	validation training.Dataset
+	loss       deep.Loss
+	state      []float64
+	rand       *rand.Rand
+}
+
+// New returns an explainer of n on validation which scores predictions by
+// loss, or by the loss n is configured with if it is nil
+func New(n *deep.Neural, validation training.Dataset, loss deep.Loss) *Explainer {
+	if loss == nil {
+		loss = deep.GetLoss(n.Config.Loss)
+	}
+	return &Explainer{
+		n:          n,
+		validation: validation,
+		loss:       loss,
+		state:      n.RecurrentState(),
+		rand:       deep.NewRand(0),
+	}
+}
+
+// WithSeed makes the permutations and samples of e draw from a source seeded
+// with seed, instead of the global source
+func (e *Explainer) WithSeed(seed int64) *Explainer {
+	e.rand = deep.NewRand(seed)
+	return e
+}
+
+// predict returns the prediction for input
+func (e *Explainer) predict(input []float64) []float64 {
+	e.n.SetRecurrentState(e.state)
+	return e.n.Predict(input)
+}
+
+// restore leaves the network in the state it was in when e was created
+func (e *Explainer) restore() {
+	e.n.SetRecurrentState(e.state)
+}
+
+// score returns the loss of the predictions for examples, whose inputs are
+// given separately
+func (e *Explainer) score(examples training.Examples, inputs [][]float64) float64 {
+	predictions := make([][]float64, len(examples))
+	ideal := make([][]float64, len(examples))
+	weights := make([]float64, len(examples))
+	mask := make([][]bool, len(examples))
+	for i, ex := range examples {
+		predictions[i] = e.predict(inputs[i])
+		ideal[i], weights[i], mask[i] = ex.Response, ex.EffectiveWeight(), ex.Mask
+	}
+	return e.loss.F(predictions, ideal, weights, mask)
+}
+
+// inputs returns copies of the inputs of examples
+func inputs(examples training.Examples) [][]float64 {
+	copies := make([][]float64, len(examples))
+	for i, ex := range examples {
+		copies[i] = append([]float64{}, ex.Input...)
+	}
+	return copies
+}
@@ -0,0 +1,37 @@
+package explain
+
+import (
+	"math/rand"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/Maxime2/go-deep/training"
+)
+
+// linear returns a network computing the dot product of its inputs with
+// weights, along with a dataset of examples it fits exactly
+func linear(weights ...float64) (*deep.Neural, training.Dataset) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     len(weights),
+		Layout:     []int{1},
+		Activation: deep.ActivationLinear,
+		Mode:       deep.ModeRegression,
+		Bias:       true,
+	})
+	for k, s := range n.Layers[0].Neurons[0].In {
+		s.Weight = 0
+		if k < len(weights) {
+			s.Weight = weights[k]
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	d := training.Dataset{Examples: make(training.Examples, 100)}
+	for i := range d.Examples {
+		input := make([]float64, len(weights))
+		for k := range input {
+			input[k] = r.NormFloat64()
+		}
+		d.Examples[i] = training.Example{Input: input, Response: []float64{deep.Dot(weights, input)}}
+	}
+	return n, d
+}
@@ -0,0 +1,53 @@
+package explain
+
+import (
+	"sort"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Importance is the increase of the validation loss when the values of a
+// feature are shuffled across the examples
+type Importance struct {
+	Feature string
+	Index   int
+	// Mean and StdDev of the increase over the repeated shuffles
+	Mean, StdDev float64
+}
+
+// PermutationImportance returns the importance of every input feature, each
+// shuffled repeats times, from most to least important. Features the network
+// relies on increase the loss when shuffled, while features it ignores do not.
+func (e *Explainer) PermutationImportance(repeats int) []Importance {
+	defer e.restore()
+
+	examples := e.validation.Examples
+	shuffled := inputs(examples)
+	base := e.score(examples, shuffled)
+
+	importances := make([]Importance, len(examples[0].Input))
+	for j := range importances {
+		increases := make([]float64, repeats)
+		for r := range increases {
+			for i, p := range e.rand.Perm(len(examples)) {
+				shuffled[i][j] = examples[p].Input[j]
+			}
+			increases[r] = e.score(examples, shuffled) - base
+		}
+		for i, ex := range examples {
+			shuffled[i][j] = ex.Input[j]
+		}
+
+		importances[j] = Importance{
+			Feature: e.validation.Feature(j),
+			Index:   j,
+			Mean:    deep.Mean(increases),
+			StdDev:  deep.StandardDeviation(increases),
+		}
+	}
+
+	sort.SliceStable(importances, func(a, b int) bool {
+		return importances[a].Mean > importances[b].Mean
+	})
+	return importances
+}
@@ -0,0 +1,25 @@
+package explain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PermutationImportance(t *testing.T) {
+	n, d := linear(2, 0, 1)
+	d.Features = []string{"a", "b", "c"}
+
+	importances := New(n, d, nil).WithSeed(1).PermutationImportance(5)
+	assert.Len(t, importances, 3)
+	assert.Equal(t, "a", importances[0].Feature)
+	assert.Equal(t, "c", importances[1].Feature)
+	assert.Equal(t, "b", importances[2].Feature)
+	assert.Equal(t, 1, importances[2].Index)
+
+	assert.True(t, importances[0].Mean > importances[1].Mean)
+	assert.True(t, importances[1].Mean > 0)
+	assert.True(t, importances[1].StdDev > 0)
+	assert.InDelta(t, 0, importances[2].Mean, 1e-12)
+	assert.InDelta(t, 0, importances[2].StdDev, 1e-12)
+}
@@ -0,0 +1,201 @@
+package explain
+
+import (
+	"math"
+
+	"github.com/Maxime2/go-deep/training"
+)
+
+// Shapley holds the contributions of the input features to an output of the
+// prediction for one input, which add up to the output less Base
+type Shapley struct {
+	Features []string
+	Values   []float64
+	// Base is the mean output over the background set
+	Base float64
+}
+
+// KernelSHAP estimates the Shapley values of the features of input for
+// output. Features left out of a coalition take their values from every
+// background example in turn, or from every validation example if background
+// is nil. All coalitions are evaluated when there are at most samples of
+// them, otherwise samples coalitions are drawn from the Shapley kernel in
+// complementary pairs.
+func (e *Explainer) KernelSHAP(input []float64, output int, background training.Examples, samples int) Shapley {
+	defer e.restore()
+	if background == nil {
+		background = e.validation.Examples
+	}
+
+	m := len(input)
+	x := make([]float64, m)
+	value := func(coalition []bool) float64 {
+		var sum float64
+		for _, b := range background {
+			for j := range x {
+				x[j] = b.Input[j]
+				if coalition[j] {
+					x[j] = input[j]
+				}
+			}
+			sum += e.predict(x)[output]
+		}
+		return sum / float64(len(background))
+	}
+
+	shapley := Shapley{
+		Features: make([]string, m),
+		Values:   make([]float64, m),
+		Base:     value(make([]bool, m)),
+	}
+	for j := range shapley.Features {
+		shapley.Features[j] = e.validation.Feature(j)
+	}
+	delta := e.predict(input)[output] - shapley.Base
+	if m == 1 {
+		shapley.Values[0] = delta
+		return shapley
+	}
+
+	// Weighted least squares of the coalition values on the features in
+	// them, with the last value eliminated so that the values add up to delta
+	coalitions, weights := e.coalitions(m, samples)
+	ata, aty := make([][]float64, m-1), make([]float64, m-1)
+	for j := range ata {
+		ata[j] = make([]float64, m-1)
+	}
+	a := make([]float64, m-1)
+	for c, coalition := range coalitions {
+		last := indicator(coalition[m-1])
+		y := value(coalition) - shapley.Base - last*delta
+		for j := range a {
+			a[j] = indicator(coalition[j]) - last
+		}
+		for j := range a {
+			for k := range a {
+				ata[j][k] += weights[c] * a[j] * a[k]
+			}
+			aty[j] += weights[c] * a[j] * y
+		}
+	}
+
+	shapley.Values[m-1] = delta
+	for j, v := range solve(ata, aty) {
+		shapley.Values[j] = v
+		shapley.Values[m-1] -= v
+	}
+	return shapley
+}
+
+// coalitions returns coalitions of m features along with their weights: all
+// of them weighted by the Shapley kernel if there are at most samples, or
+// else samples of them drawn from it
+func (e *Explainer) coalitions(m, samples int) ([][]bool, []float64) {
+	var coalitions [][]bool
+	var weights []float64
+
+	if m < 31 && 1<<uint(m)-2 <= samples {
+		for mask := 1; mask < 1<<uint(m)-1; mask++ {
+			coalition := make([]bool, m)
+			var size int
+			for j := range coalition {
+				coalition[j] = mask&(1<<uint(j)) != 0
+				size += int(indicator(coalition[j]))
+			}
+			coalitions = append(coalitions, coalition)
+			weights = append(weights, kernel(m, size))
+		}
+		return coalitions, weights
+	}
+
+	// Coalition sizes are drawn in proportion to the kernel mass of all
+	// coalitions of that size, and the members uniformly
+	sizes := make([]float64, m)
+	var total float64
+	for s := 1; s < m; s++ {
+		total += kernel(m, s) * binomial(m, s)
+		sizes[s] = total
+	}
+	for len(coalitions) < samples {
+		u := e.rand.Float64() * total
+		size := 1
+		for sizes[size] < u {
+			size++
+		}
+		coalition, complement := make([]bool, m), make([]bool, m)
+		for i, j := range e.rand.Perm(m) {
+			coalition[j] = i < size
+			complement[j] = i >= size
+		}
+		coalitions = append(coalitions, coalition, complement)
+		weights = append(weights, 1, 1)
+	}
+	return coalitions, weights
+}
+
+// kernel is the Shapley kernel weight of a coalition of size out of m features
+func kernel(m, size int) float64 {
+	return float64(m-1) / (binomial(m, size) * float64(size*(m-size)))
+}
+
+// binomial is the number of ways to choose k of n
+func binomial(n, k int) float64 {
+	c := 1.
+	for i := 1; i <= k; i++ {
+		c = c * float64(n-k+i) / float64(i)
+	}
+	return c
+}
+
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// solve solves a x = b by gaussian elimination with partial pivoting, leaving
+// components which a does not determine zero
+func solve(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	pivots := make([]int, 0, n)
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = i
+	}
+
+	for col, r := 0, 0; col < n && r < n; col++ {
+		best := r
+		for i := r + 1; i < n; i++ {
+			if math.Abs(a[rows[i]][col]) > math.Abs(a[rows[best]][col]) {
+				best = i
+			}
+		}
+		if math.Abs(a[rows[best]][col]) < 1e-12 {
+			continue
+		}
+		rows[r], rows[best] = rows[best], rows[r]
+		p := rows[r]
+		for i := r + 1; i < n; i++ {
+			q := rows[i]
+			f := a[q][col] / a[p][col]
+			for k := col; k < n; k++ {
+				a[q][k] -= f * a[p][k]
+			}
+			b[q] -= f * b[p]
+		}
+		pivots = append(pivots, col)
+		r++
+	}
+
+	x := make([]float64, n)
+	for r := len(pivots) - 1; r >= 0; r-- {
+		col, p := pivots[r], rows[r]
+		sum := b[p]
+		for k := col + 1; k < n; k++ {
+			sum -= a[p][k] * x[k]
+		}
+		x[col] = sum / a[p][col]
+	}
+	return x
+}
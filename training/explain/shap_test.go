@@ -0,0 +1,61 @@
+package explain
+
+import (
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KernelSHAP(t *testing.T) {
+	for _, weights := range [][]float64{
+		{1.5},
+		{2, -1, 0.5},
+		{1, -2, 0.5, 0, 3, -1, 2, 0.25, -0.5, 1, 1.5, -3},
+	} {
+		n, d := linear(weights...)
+		background := d.Examples[:20]
+		input := d.Examples[50].Input
+
+		// The Shapley values of a linear model are exact however few
+		// coalitions are sampled
+		shapley := New(n, d, nil).WithSeed(1).KernelSHAP(input, 0, background, 100)
+		assert.Len(t, shapley.Values, len(weights))
+		var base float64
+		for _, b := range background {
+			base += n.Predict(b.Input)[0] / float64(len(background))
+		}
+		assert.InDelta(t, base, shapley.Base, 1e-9)
+		for j, w := range weights {
+			var mean float64
+			for _, b := range background {
+				mean += b.Input[j] / float64(len(background))
+			}
+			assert.InDelta(t, w*(input[j]-mean), shapley.Values[j], 1e-9)
+		}
+	}
+}
+
+func Test_KernelSHAPRecurrent(t *testing.T) {
+	_, d := linear(1, 2, 3, 4)
+	d.Features = []string{"a", "b", "c", "d"}
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     4,
+		Layout:     []int{6, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeRegression,
+		Bias:       true,
+		Seed:       1,
+	})
+	n.Predict(d.Examples[0].Input)
+	input := d.Examples[1].Input
+	state := n.RecurrentState()
+
+	e := New(n, d, nil)
+	shapley := e.KernelSHAP(input, 0, d.Examples[:10], 100)
+	assert.Equal(t, d.Features, shapley.Features)
+	assert.Equal(t, state, n.RecurrentState())
+
+	// The values are efficient
+	assert.InDelta(t, n.Predict(input)[0]-shapley.Base, deep.Sum(shapley.Values), 1e-9)
+}
@@ -0,0 +1,59 @@
+package federated
+
+import (
+	deep "github.com/Maxime2/go-deep"
+	"github.com/Maxime2/go-deep/training"
+)
+
+// TrainerFunc returns a trainer configured with opts, such as the proximal
+// term of FedProx, to train a client for a round
+type TrainerFunc func(opts ...training.Option) training.Trainer
+
+// Client trains copies of the global network on its shard of the examples
+type Client struct {
+	Config   *deep.Config
+	Examples training.Examples
+	Trainer  TrainerFunc
+}
+
+// Request asks a client to train the global network for a round
+type Request struct {
+	// Weights of the global network
+	Weights [][][]float64
+	// Epochs of local training
+	Epochs int
+	// Mu weighs the proximal term keeping the weights near the global
+	// weights; there is none if it is zero
+	Mu float64
+}
+
+// Update is the outcome of a round of training on a client
+type Update struct {
+	// Weights of the trained network
+	Weights [][][]float64
+	// Examples is the number of examples they were trained on
+	Examples int
+}
+
+// Train trains a copy of the global network on the examples of c
+func (c *Client) Train(r Request) Update {
+	n := deep.NewNeural(c.Config)
+	n.ApplyWeights(r.Weights)
+
+	var opts []training.Option
+	if r.Mu != 0 {
+		opts = append(opts, training.WithProximal(r.Weights, r.Mu))
+	}
+	c.Trainer(opts...).Train(n, c.Examples, nil, r.Epochs)
+	return Update{Weights: n.Weights(), Examples: len(c.Examples)}
+}
+
+// Clients returns clients of networks configured by c, each holding one of
+// shards and training with trainer
+func Clients(c *deep.Config, shards []training.Examples, trainer TrainerFunc) Local {
+	clients := make(Local, len(shards))
+	for i, shard := range shards {
+		clients[i] = &Client{Config: c, Examples: shard, Trainer: trainer}
+	}
+	return clients
+}
@@ -0,0 +1,123 @@
+// Package federated simulates federated training of a global network by
+// clients which keep their examples to themselves: federated averaging
+// (FedAvg, McMahan et al., 2017) and its proximal variant (FedProx, Li et
+// al., 2020).
+package federated
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/Maxime2/go-deep/training"
+)
+
+// Federation trains a global network by rounds, in each of which a sample of
+// the clients train it on their examples, and their weights are averaged
+// weighted by the number of examples
+type Federation struct {
+	transport Transport
+	epochs    int
+	fraction  float64
+	mu        float64
+	verbosity int
+	printer   *training.StatsPrinter
+	rand      *rand.Rand
+}
+
+// NewFederation returns a federation of the clients reached by transport,
+// which train for epochs per round. A fraction of the clients, at least one,
+// is sampled every round, or all of them if it is zero. The proximal term of
+// FedProx is weighed by mu, and left out, as in FedAvg, if it is zero.
+func NewFederation(transport Transport, epochs int, fraction, mu float64, verbosity int) *Federation {
+	return &Federation{
+		transport: transport,
+		epochs:    epochs,
+		fraction:  fraction,
+		mu:        mu,
+		verbosity: verbosity,
+		printer:   training.NewStatsPrinter(),
+		rand:      deep.NewRand(0),
+	}
+}
+
+// WithSeed makes f sample clients drawing from a source seeded with seed,
+// instead of the global source
+func (f *Federation) WithSeed(seed int64) *Federation {
+	f.rand = deep.NewRand(seed)
+	return f
+}
+
+// Train trains n for rounds, printing progress on validation
+func (f *Federation) Train(n *deep.Neural, validation training.Examples, rounds int) error {
+	f.printer.Init(n)
+	ts := time.Now()
+	for round := 1; round <= rounds; round++ {
+		if err := f.Round(n); err != nil {
+			return err
+		}
+		if f.verbosity > 0 && round%f.verbosity == 0 && len(validation) > 0 {
+			f.printer.PrintProgress(n, validation, time.Since(ts), round)
+		}
+	}
+	return nil
+}
+
+// Round trains n for a round on a sample of the clients
+func (f *Federation) Round(n *deep.Neural) error {
+	r := Request{Weights: n.Weights(), Epochs: f.epochs, Mu: f.mu}
+
+	var updates []Update
+	for _, i := range f.sample() {
+		u, err := f.transport.Send(i, r)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, u)
+	}
+	n.ApplyWeights(average(updates))
+	return nil
+}
+
+// sample returns the clients drawn for a round, in order, so that their
+// weights are averaged in the same order whatever the draw
+func (f *Federation) sample() []int {
+	clients := f.transport.Clients()
+	count := clients
+	if f.fraction != 0 {
+		count = int(math.Max(1, math.Round(f.fraction*float64(clients))))
+	}
+	sample := f.rand.Perm(clients)[:count]
+	sort.Ints(sample)
+	return sample
+}
+
+// average returns the mean of the weights of updates, weighted by the number
+// of examples
+func average(updates []Update) [][][]float64 {
+	var total float64
+	for _, u := range updates {
+		total += float64(u.Examples)
+	}
+
+	mean := make([][][]float64, len(updates[0].Weights))
+	for i, l := range updates[0].Weights {
+		mean[i] = make([][]float64, len(l))
+		for j, neuron := range l {
+			mean[i][j] = make([]float64, len(neuron))
+		}
+	}
+	for _, u := range updates {
+		share := float64(u.Examples) / total
+		for i, l := range u.Weights {
+			for j, neuron := range l {
+				for k, w := range neuron {
+					mean[i][j][k] += share * w
+				}
+			}
+		}
+	}
+	return mean
+}
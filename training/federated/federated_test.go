@@ -0,0 +1,140 @@
+package federated
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/Maxime2/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+var config = &deep.Config{
+	Inputs:     2,
+	Layout:     []int{8, 2},
+	Activation: deep.ActivationTanh,
+	Mode:       deep.ModeMultiClass,
+	Bias:       true,
+	Seed:       1,
+}
+
+// shards returns count shards of examples of two classes centred at (-1, -1)
+// and (1, 1), the i-th holding size·(i+1) of them
+func shards(count, size int, seed int64) []training.Examples {
+	r := rand.New(rand.NewSource(seed))
+	shards := make([]training.Examples, count)
+	for i := range shards {
+		shards[i] = make(training.Examples, size*(i+1))
+		for j := range shards[i] {
+			c := j % 2
+			center := float64(2*c - 1)
+			shards[i][j] = training.Example{
+				Input:    []float64{center + 0.5*r.NormFloat64(), center + 0.5*r.NormFloat64()},
+				Response: make([]float64, 2),
+			}
+			shards[i][j].Response[c] = 1
+		}
+	}
+	return shards
+}
+
+func adam(opts ...training.Option) training.Trainer {
+	return training.NewBatchTrainer(training.NewAdam(0.01, 0, 0, 0), 0, 10, 1, append(opts, training.WithSeed(1))...)
+}
+
+// constant sets every weight to the number of examples
+type constant struct{}
+
+func (constant) Train(n *deep.Neural, examples, validation training.Examples, iterations int) {
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				s.Weight = float64(len(examples))
+			}
+		}
+	}
+}
+
+func accuracy(n *deep.Neural, examples training.Examples) float64 {
+	var correct int
+	for _, e := range examples {
+		if deep.ArgMax(n.Predict(e.Input)) == deep.ArgMax(e.Response) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(examples))
+}
+
+func Test_Average(t *testing.T) {
+	clients := Clients(config, shards(3, 10, 1), func(opts ...training.Option) training.Trainer { return constant{} })
+	n := deep.NewNeural(config)
+	assert.NoError(t, NewFederation(clients, 1, 0, 0, 0).Round(n))
+
+	// Shards of 10, 20 and 30 examples average to (10² + 20² + 30²) / 60
+	for _, l := range n.Weights() {
+		for _, neuron := range l {
+			for _, w := range neuron {
+				assert.InDelta(t, 1400./60, w, 1e-12)
+			}
+		}
+	}
+}
+
+func Test_Sample(t *testing.T) {
+	clients := make(Local, 10)
+	for _, c := range []struct {
+		fraction float64
+		count    int
+	}{{0, 10}, {0.3, 3}, {0.01, 1}, {1, 10}} {
+		sample := NewFederation(clients, 1, c.fraction, 0, 0).WithSeed(1).sample()
+		assert.Len(t, sample, c.count)
+		seen := map[int]bool{}
+		for _, i := range sample {
+			assert.False(t, seen[i])
+			seen[i] = true
+		}
+	}
+}
+
+func Test_FedAvg(t *testing.T) {
+	test := shards(1, 200, 2)[0]
+	for _, mu := range []float64{0, 0.1} {
+		n := deep.NewNeural(config)
+		f := NewFederation(Clients(config, shards(5, 20, 1), adam), 2, 0.6, mu, 0).WithSeed(1)
+		assert.NoError(t, f.Train(n, test, 20))
+		assert.True(t, accuracy(n, test) > 0.9, "mu = %v", mu)
+	}
+}
+
+func Test_TCP(t *testing.T) {
+	clients := Clients(config, shards(3, 20, 1), adam)
+	addresses := make(TCP, len(clients))
+	for i, c := range clients {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer l.Close()
+		go Serve(l, c)
+		addresses[i] = l.Addr().String()
+	}
+
+	local, remote := deep.NewNeural(config), deep.NewNeural(config)
+	assert.NoError(t, NewFederation(clients, 1, 0, 0.1, 0).Train(local, nil, 3))
+	assert.NoError(t, NewFederation(addresses, 1, 0, 0.1, 0).Train(remote, nil, 3))
+	assert.Equal(t, local.Weights(), remote.Weights())
+
+	assert.Error(t, NewFederation(TCP{"127.0.0.1:1"}, 1, 0, 0, 0).Round(remote))
+
+	// A failed exchange drops its client only, and serving ends with the
+	// listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	served := make(chan error)
+	go func() { served <- Serve(l, clients[0]) }()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	conn.Close()
+	assert.NoError(t, NewFederation(TCP{l.Addr().String()}, 1, 0, 0, 0).Round(remote))
+	l.Close()
+	assert.Error(t, <-served)
+}
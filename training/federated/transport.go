@@ -0,0 +1,79 @@
+package federated
+
+import (
+	"encoding/gob"
+	"log"
+	"net"
+)
+
+// Transport delivers requests to clients and their updates back
+type Transport interface {
+	// Clients is the number of clients
+	Clients() int
+	// Send sends r to the i-th client and returns its update
+	Send(i int, r Request) (Update, error)
+}
+
+// Local is a transport to clients in the same process
+type Local []*Client
+
+// Clients is the number of clients
+func (l Local) Clients() int {
+	return len(l)
+}
+
+// Send trains the i-th client
+func (l Local) Send(i int, r Request) (Update, error) {
+	return l[i].Train(r), nil
+}
+
+// TCP is a transport to clients served at addresses, such as by Serve on
+// localhost, which stands in for a network of devices
+type TCP []string
+
+// Clients is the number of clients
+func (t TCP) Clients() int {
+	return len(t)
+}
+
+// Send sends r to the client at the i-th address and returns its update
+func (t TCP) Send(i int, r Request) (Update, error) {
+	conn, err := net.Dial("tcp", t[i])
+	if err != nil {
+		return Update{}, err
+	}
+	defer conn.Close()
+
+	if err := gob.NewEncoder(conn).Encode(r); err != nil {
+		return Update{}, err
+	}
+	var u Update
+	err = gob.NewDecoder(conn).Decode(&u)
+	return u, err
+}
+
+// Serve trains c on the request of every connection accepted from l and
+// replies with its update, until l fails to accept, such as once it is
+// closed. A failed request or reply is logged and its connection dropped.
+func Serve(l net.Listener, c *Client) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		if err := serve(conn, c); err != nil {
+			log.Printf("federated: serving %s: %v", conn.RemoteAddr(), err)
+		}
+	}
+}
+
+// serve trains c on the request read from conn and replies with its update
+func serve(conn net.Conn, c *Client) error {
+	defer conn.Close()
+
+	var r Request
+	if err := gob.NewDecoder(conn).Decode(&r); err != nil {
+		return err
+	}
+	return gob.NewEncoder(conn).Encode(c.Train(r))
+}
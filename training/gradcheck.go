@@ -0,0 +1,104 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// GradCheck compares the gradients of the loss of n on e which both trainers
+// backpropagate with central finite differences, and returns the worst
+// relative error over all weights. Recurrent weights, whose gradients are
+// truncated to the current step, are left out, and the recurrent state of n
+// is held fixed while the loss is evaluated. Gradients accumulated in n are
+// reset.
+func GradCheck(n *deep.Neural, e Example) float64 {
+	state := recurrentState(n)
+	restore := func() {
+		for s, out := range state {
+			s.Out = out
+		}
+	}
+
+	n.ZeroGradients()
+	online := NewTrainer(NewSGD(0, 0, 0, false), 0)
+	online.internal = newTraining(n.Layers)
+	restore()
+	n.Forward(e.Input)
+	online.calculateDeltas(n, e)
+	analytic := n.Gradients()
+	n.ZeroGradients()
+
+	batch := NewBatchTrainer(NewSGD(0, 0, 0, false), 0, 1, 1)
+	batch.internalb = newBatchTraining(n.Layers, 1)
+	restore()
+	j := job{e: e}
+	if grads := batchGradients(n, Examples{e}); grads != nil {
+		j.grad = grads[0]
+	}
+	restore()
+	n.Forward(e.Input)
+	batch.calculateDeltas(n, j, 0)
+	batched := n.Gradients()
+	n.ZeroGradients()
+
+	const h = 1e-5
+	var worst float64
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if _, ok := state[s]; ok {
+					continue
+				}
+				w := s.Weight
+				s.Weight = w + h
+				restore()
+				plus := objective(n, e)
+				s.Weight = w - h
+				restore()
+				minus := objective(n, e)
+				s.Weight = w
+				numeric := (plus - minus) / (2 * h)
+
+				for _, g := range []float64{analytic[i][j][k], batched[i][j][k]} {
+					worst = math.Max(worst, relativeError(g, numeric))
+				}
+			}
+		}
+	}
+	restore()
+	return worst
+}
+
+// recurrentState returns the recurrent synapses of n along with their outputs
+func recurrentState(n *deep.Neural) map[*deep.Synapse]float64 {
+	state := make(map[*deep.Synapse]float64)
+	for _, l := range n.Layers[:len(n.Layers)-1] {
+		for _, neuron := range l.Neurons {
+			s := neuron.Out[len(neuron.Out)-1]
+			state[s] = s.Out
+		}
+	}
+	return state
+}
+
+// objective is the loss of n on e whose gradient the trainers follow: the
+// weighted loss of e, where squared errors are halved
+func objective(n *deep.Neural, e Example) float64 {
+	estimate := n.Predict(e.Input)
+	if n.Config.Loss == deep.LossMeanSquared {
+		var sum float64
+		for i := range estimate {
+			if e.observed(i) {
+				sum += (estimate[i] - e.Response[i]) * (estimate[i] - e.Response[i])
+			}
+		}
+		return e.EffectiveWeight() * sum / 2
+	}
+	ideal, weights, mask := Examples{e}.targets()
+	return e.EffectiveWeight() * deep.GetLoss(n.Config.Loss).F([][]float64{estimate}, ideal, weights, mask)
+}
+
+func relativeError(a, b float64) float64 {
+	return math.Abs(a-b) / math.Max(math.Max(math.Abs(a), math.Abs(b)), 1e-6)
+}
@@ -0,0 +1,86 @@
+package training
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+// consistent reports whether the deltas the trainers compute for the loss of
+// c are exact, which requires the output deltas to be the gradient with
+// respect to the pre-activations of the output layer. Hidden softmax layers
+// pass on their values before normalizing them, so they act linearly.
+func consistent(c *deep.Config) bool {
+	out := c.LayerActivation(len(c.Layout) - 1)
+	switch c.Loss {
+	case deep.LossCox:
+		// The partial likelihood of a single example is constant
+		return true
+	case deep.LossCrossEntropy:
+		return out == deep.ActivationSoftmax
+	case deep.LossBinaryCrossEntropy:
+		return out == deep.ActivationSigmoid
+	}
+	return out != deep.ActivationSoftmax
+}
+
+func Test_GradCheck(t *testing.T) {
+	activations := []deep.ActivationType{deep.ActivationNone, deep.ActivationSigmoid, deep.ActivationTanh,
+		deep.ActivationReLU, deep.ActivationLinear, deep.ActivationSoftmax}
+	losses := []deep.LossType{deep.LossNone, deep.LossCrossEntropy, deep.LossBinaryCrossEntropy,
+		deep.LossMeanSquared, deep.LossCox, deep.LossMixtureDensity}
+	modes := []deep.Mode{deep.ModeDefault, deep.ModeMultiClass, deep.ModeRegression, deep.ModeBinary,
+		deep.ModeMultiLabel, deep.ModeSurvival, deep.ModeMixtureDensity}
+
+	for _, activation := range activations {
+		for _, loss := range losses {
+			for _, mode := range modes {
+				c := &deep.Config{
+					Inputs:     3,
+					Activation: activation,
+					Loss:       loss,
+					Mode:       mode,
+					Components: 2,
+					Init:       &deep.Initializer{Kind: "normal", Std: 0.7},
+					Bias:       true,
+					Seed:       1,
+				}
+				r := rand.New(rand.NewSource(1))
+				e := Example{Input: []float64{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}}.Weighted(1.5)
+
+				// Resolve the default loss of the mode before shaping the targets
+				resolved := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{deep.MixtureOutputs(1, 1)}, Mode: mode, Loss: loss}).Config.Loss
+				switch resolved {
+				case deep.LossCrossEntropy:
+					c.Layout = []int{4, 3}
+					e.Response = []float64{0, 1, 0}
+				case deep.LossBinaryCrossEntropy:
+					c.Layout = []int{4, 3}
+					e.Response = []float64{0, 1, 1}
+				case deep.LossMeanSquared:
+					c.Layout = []int{4, 3}
+					e.Response = []float64{0.3, -0.2, 0.8}
+					e.Mask = []bool{true, false, true}
+				case deep.LossCox:
+					c.Layout = []int{4, 1}
+					e = NewSurvivalExample(e.Input, 2, true)
+				case deep.LossMixtureDensity:
+					c.Layout = []int{4, deep.MixtureOutputs(2, 1)}
+					e.Response = []float64{0.4}
+				}
+				n := deep.NewNeural(c)
+
+				err := GradCheck(n, e)
+				name := fmt.Sprintf("activation %d/loss %s/mode %d", activation, resolved, mode)
+				if consistent(c) {
+					assert.True(t, err < 1e-4, "%s: relative error %g", name, err)
+				} else {
+					assert.False(t, err < 1e-4, "%s: mismatched output deltas not detected", name)
+				}
+			}
+		}
+	}
+}
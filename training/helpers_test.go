@@ -0,0 +1,19 @@
+package training
+
+import (
+	deep "github.com/Maxime2/go-deep"
+)
+
+// standardizeFeatures standardizes every input feature across examples
+func standardizeFeatures(examples Examples) {
+	for j := range examples[0].Input {
+		feature := make([]float64, len(examples))
+		for i, e := range examples {
+			feature[i] = e.Input[j]
+		}
+		deep.Standardize(feature)
+		for i, e := range examples {
+			e.Input[j] = feature[i]
+		}
+	}
+}
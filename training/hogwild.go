@@ -0,0 +1,141 @@
+package training
+
+import (
+	"fmt"
+	"sync"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// HogwildTrainer implements lock-free parallel online training (Hogwild!,
+// Niu et al., 2011). Every goroutine trains on its part of the examples one
+// at a time, reading the shared weights and adding its updates to them
+// atomically, but without locks or waiting for the others, so that neither
+// weights are copied into worker networks nor gradients reduced per batch,
+// as BatchTrainer does.
+//
+// Updates are those of SGD without momentum. They are computed from weights
+// that the other goroutines may be updating meanwhile, so that a gradient
+// may be stale, or even of a mix of weights that never were at once. When
+// examples touch few weights, as with sparse inputs, updates seldom collide
+// and training converges about as online training does. When every update
+// touches every weight, as in small dense networks, stale gradients add noise
+// which a smaller learning rate has to make up for. Every example also pays
+// for updating the weights, which BatchTrainer does once a batch, so that
+// with a single goroutine it trains on fewer examples per second;
+// Benchmark_Throughput and Benchmark_Convergence compare the two.
+// Training is reproducible only with a parallelism of 1, in which case it is
+// that of an OnlineTrainer with SGD.
+//
+// Of the options, seed, distillation, pruning, which takes effect at the end
+// of every epoch, and callbacks, which are notified of epochs only, are
+// supported; the others panic.
+type HogwildTrainer struct {
+	options
+	lr          float64
+	decay       float64
+	verbosity   int
+	parallelism int
+	printer     *StatsPrinter
+}
+
+// NewHogwildTrainer returns a HogwildTrainer with learning rate lr, decaying
+// by decay every epoch, and parallelism goroutines
+func NewHogwildTrainer(lr, decay float64, verbosity, parallelism int, opts ...Option) *HogwildTrainer {
+	o := newOptions(opts)
+	o.supportOnly("a Hogwild trainer", "WithDistillation", "WithPruning", "WithCallbacks")
+	return &HogwildTrainer{
+		options:     o,
+		lr:          fparam(lr, 0.01),
+		decay:       decay,
+		verbosity:   verbosity,
+		parallelism: iparam(parallelism, 1),
+		printer:     NewStatsPrinter(),
+	}
+}
+
+// Train trains n. Like OnlineTrainer, it panics with a loss which couples the
+// examples of a batch.
+func (t *HogwildTrainer) Train(n *deep.Neural, examples, validation Examples, iterations int) {
+	if coupled(n) {
+		panic(fmt.Sprintf("Hogwild training is not supported with %s loss", n.Config.Loss))
+	}
+	train := make(Examples, len(examples))
+	copy(train, t.teach(n, examples))
+
+	t.printer.teacher = t.teacher()
+	t.printer.Verbosity = t.verbosity
+
+	shared := deep.NewSharedWeights(n)
+	nets := make([]*deep.Neural, t.parallelism)
+	deltas := make([][]float64, t.parallelism)
+	for w := range nets {
+		nets[w] = deep.NewNeural(n.Config)
+		nets[w].Share(shared)
+		deltas[w] = make([]float64, len(n.Layers[len(n.Layers)-1].Neurons))
+	}
+	prune := func() {
+		for _, net := range nets {
+			for i, l := range n.Layers {
+				for j, neuron := range l.Neurons {
+					for k, s := range neuron.In {
+						net.Layers[i].Neurons[j].In[k].Pruned = s.Pruned
+					}
+				}
+			}
+		}
+	}
+	prune()
+
+	p := t.begin(n, validation, t.printer)
+	for it := 1; it <= iterations; it++ {
+		p.epochBegin(n, it)
+		train.ShuffleWith(t.rand)
+
+		wg := sync.WaitGroup{}
+		wg.Add(len(nets))
+		for w := range nets {
+			go func(w int) {
+				defer wg.Done()
+				for i := w * len(train) / len(nets); i < (w+1)*len(train)/len(nets); i++ {
+					t.learn(nets[w], shared, train[i], deltas[w], it)
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		shared.Load(n)
+		t.prune(n, it)
+		shared.Store(n)
+		prune()
+
+		if p.epochEnd(n) {
+			break
+		}
+	}
+	p.trainEnd(n)
+}
+
+// learn updates the shared weights with the gradients of n on e, computed
+// with the shared weights as they are read
+func (t *HogwildTrainer) learn(n *deep.Neural, shared deep.SharedWeights, e Example, deltas []float64, it int) {
+	n.Forward(e.Input)
+	outputDeltas(n, e, deltas)
+	t.distill(n, e, deltas)
+	n.BackwardDeltas(deltas)
+
+	scheduled := t.lr / (1 + t.decay*float64(it))
+	var idx int
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if s.Gradient != 0 && !s.Pruned {
+					lr := scheduled / (1 + scheduled*s.In*s.In)
+					shared.Add(idx, -lr*s.Gradient)
+				}
+				s.Gradient = 0
+				idx++
+			}
+		}
+	}
+}
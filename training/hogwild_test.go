@@ -0,0 +1,112 @@
+package training
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Hogwild(t *testing.T) {
+	train, test := blobs(400, 1), blobs(200, 2)
+	config := &deep.Config{
+		Inputs:     2,
+		Layout:     []int{8, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+
+	// With a single goroutine it is online training with SGD
+	online, hogwild := deep.NewNeural(config), deep.NewNeural(config)
+	NewTrainer(NewSGD(0.01, 0, 0.1, false), 0, WithSeed(1)).Train(online, append(Examples{}, train...), nil, 5)
+	NewHogwildTrainer(0.01, 0.1, 0, 1, WithSeed(1)).Train(hogwild, train, nil, 5)
+	assert.Equal(t, online.Weights(), hogwild.Weights())
+
+	n := deep.NewNeural(config)
+	NewHogwildTrainer(0.01, 0, 0, 4, WithSeed(1)).Train(n, train, nil, 10)
+	assert.True(t, accuracy(n, test) > 0.9)
+
+	survival := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{1}, Mode: deep.ModeSurvival, Seed: 1})
+	assert.Panics(t, func() { NewHogwildTrainer(0.01, 0, 0, 1).Train(survival, train, nil, 1) })
+
+	assert.Panics(t, func() { NewHogwildTrainer(0.01, 0, 0, 1, WithPrivacy(Privacy{Clip: 1})) })
+	assert.Panics(t, func() { NewHogwildTrainer(0.01, 0, 0, 1, WithClipping(Clipping{Value: 1})) })
+	assert.NotPanics(t, func() { NewHogwildTrainer(0.01, 0, 0, 1, WithPruning(Pruning{Final: 0.5, End: 2})) })
+}
+
+// Benchmark_Throughput compares the examples trained on per second by
+// HogwildTrainer and BatchTrainer with increasing parallelism
+func Benchmark_Throughput(b *testing.B) {
+	train := blobs(2000, 1)
+	config := &deep.Config{
+		Inputs:     2,
+		Layout:     []int{64, 64, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		for _, c := range []struct {
+			name    string
+			trainer Trainer
+		}{
+			{"hogwild", NewHogwildTrainer(0.01, 0, 0, parallelism, WithSeed(1))},
+			{"batch", NewBatchTrainer(NewSGD(0.01, 0, 0, false), 0, 32, parallelism, WithSeed(1))},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", c.name, parallelism), func(b *testing.B) {
+				n := deep.NewNeural(config)
+				ts := time.Now()
+				for i := 0; i < b.N; i++ {
+					c.trainer.Train(n, train, nil, 1)
+				}
+				b.ReportMetric(float64(b.N*len(train))/time.Since(ts).Seconds(), "examples/s")
+			})
+		}
+	}
+}
+
+// Benchmark_Convergence compares the validation loss and accuracy reached
+// in 5 epochs by HogwildTrainer, OnlineTrainer and BatchTrainer with
+// increasing parallelism
+func Benchmark_Convergence(b *testing.B) {
+	train, test := blobs(2000, 1), blobs(1000, 2)
+	config := &deep.Config{
+		Inputs:     2,
+		Layout:     []int{64, 64, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		for _, c := range []struct {
+			name    string
+			trainer Trainer
+		}{
+			{"hogwild", NewHogwildTrainer(0.01, 0, 0, parallelism, WithSeed(1))},
+			{"online", NewTrainer(NewSGD(0.01, 0, 0, false), 0, WithSeed(1))},
+			{"batch", NewBatchTrainer(NewSGD(0.01, 0, 0, false), 0, 32, parallelism, WithSeed(1))},
+		} {
+			if c.name == "online" && parallelism > 1 {
+				continue
+			}
+			b.Run(fmt.Sprintf("%s/%d", c.name, parallelism), func(b *testing.B) {
+				var loss, acc float64
+				for i := 0; i < b.N; i++ {
+					n := deep.NewNeural(config)
+					c.trainer.Train(n, append(Examples{}, train...), nil, 5)
+					loss, acc = crossValidate(n, test), accuracy(n, test)
+				}
+				b.ReportMetric(loss, "loss")
+				b.ReportMetric(acc, "accuracy")
+			})
+		}
+	}
+}
@@ -1,20 +1,103 @@
 package training
 
-import "math/rand"
+import (
+	"fmt"
+	"math/rand"
+
+	deep "github.com/Maxime2/go-deep"
+)
 
 // Example is an input-target pair
 type Example struct {
 	Input    []float64
 	Response []float64
+	// Weight scales the contribution of the example to the loss and its
+	// gradient. Unset, it weighs the example as 1, whereas a weight of 0
+	// leaves the example out of training. Weighted sets it.
+	Weight *float64 `json:",omitempty"`
+	// Mask optionally marks which responses are labelled; responses masked
+	// out by false contribute neither to the loss nor to the gradient
+	Mask []bool
+
+	// teacher holds the outputs of a teacher network for distillation
+	teacher []float64
 }
 
 // Examples is a set of input-output pairs
 type Examples []Example
 
+// Dataset is a set of examples along with the names of their input features
+type Dataset struct {
+	Examples Examples
+	// Features names the inputs of the examples, in order
+	Features []string
+}
+
+// Feature returns the name of the j-th input feature, or x<j> if it is unnamed
+func (d Dataset) Feature(j int) string {
+	if j < len(d.Features) && d.Features[j] != "" {
+		return d.Features[j]
+	}
+	return fmt.Sprintf("x%d", j)
+}
+
+// Index returns the index of the input feature named name, or -1 if there is
+// none
+func (d Dataset) Index(name string) int {
+	for j, feature := range d.Features {
+		if feature == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// Weighted returns a copy of e weighing w
+func (e Example) Weighted(w float64) Example {
+	e.Weight = &w
+	return e
+}
+
+// EffectiveWeight is the weight of e, which is 1 unless it is set
+func (e Example) EffectiveWeight() float64 {
+	if e.Weight == nil {
+		return 1
+	}
+	return *e.Weight
+}
+
+// observed reports whether the i-th response of e is labelled
+func (e Example) observed(i int) bool {
+	return e.Mask == nil || e.Mask[i]
+}
+
+// Inputs returns the inputs of all examples
+func (e Examples) Inputs() [][]float64 {
+	inputs := make([][]float64, len(e))
+	for i, ex := range e {
+		inputs[i] = ex.Input
+	}
+	return inputs
+}
+
+// targets returns the responses, weights and masks of e as expected by deep.Loss
+func (e Examples) targets() (ideal [][]float64, weights []float64, mask [][]bool) {
+	ideal, weights, mask = make([][]float64, len(e)), make([]float64, len(e)), make([][]bool, len(e))
+	for i, ex := range e {
+		ideal[i], weights[i], mask[i] = ex.Response, ex.EffectiveWeight(), ex.Mask
+	}
+	return
+}
+
 // Shuffle shuffles slice in-place
 func (e Examples) Shuffle() {
+	e.ShuffleWith(deep.NewRand(0))
+}
+
+// ShuffleWith shuffles slice in-place drawing from r
+func (e Examples) ShuffleWith(r *rand.Rand) {
 	for i := range e {
-		j := rand.Intn(i + 1)
+		j := r.Intn(i + 1)
 		e[i], e[j] = e[j], e[i]
 	}
 }
@@ -22,8 +105,14 @@ func (e Examples) Shuffle() {
 // Split assigns each element to two new slices
 // according to probability p
 func (e Examples) Split(p float64) (first, second Examples) {
+	return e.SplitWith(p, deep.NewRand(0))
+}
+
+// SplitWith assigns each element to two new slices
+// according to probability p drawing from r
+func (e Examples) SplitWith(p float64, r *rand.Rand) (first, second Examples) {
 	for i := 0; i < len(e); i++ {
-		if p > rand.Float64() {
+		if p > r.Float64() {
 			first = append(first, e[i])
 		} else {
 			second = append(second, e[i])
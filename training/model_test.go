@@ -37,3 +37,30 @@ func Test_Split(t *testing.T) {
 	assert.InEpsilon(t, len(a), 50, 0.1)
 	assert.InEpsilon(t, len(b), 50, 0.1)
 }
+
+func Test_ShuffleWith(t *testing.T) {
+	e := make(Examples, 20)
+	for i := range e {
+		e[i].Input = []float64{float64(i)}
+	}
+	a, b := append(Examples{}, e...), append(Examples{}, e...)
+
+	a.ShuffleWith(rand.New(rand.NewSource(1)))
+	b.ShuffleWith(rand.New(rand.NewSource(1)))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, e, a)
+
+	first, _ := e.SplitWith(0.5, rand.New(rand.NewSource(1)))
+	again, _ := e.SplitWith(0.5, rand.New(rand.NewSource(1)))
+	assert.Equal(t, first, again)
+}
+
+func Test_DatasetFeatures(t *testing.T) {
+	d := Dataset{Features: []string{"alcohol", "", "ash"}}
+
+	assert.Equal(t, "alcohol", d.Feature(0))
+	assert.Equal(t, "x1", d.Feature(1))
+	assert.Equal(t, "x3", d.Feature(3))
+	assert.Equal(t, 2, d.Index("ash"))
+	assert.Equal(t, -1, d.Index("hue"))
+}
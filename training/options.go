@@ -0,0 +1,115 @@
+package training
+
+import (
+	"fmt"
+	"math/rand"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Option configures a trainer
+type Option func(*options)
+
+type options struct {
+	rand *rand.Rand
+
+	fakeQuantize bool
+	granularity  deep.Granularity
+
+	pruning *Pruning
+
+	distillation *distillation
+
+	constraints map[int][]Constraint
+
+	clipping *clipping
+
+	adversarial *adversarial
+
+	privacy *privacy
+
+	proximal *proximal
+
+	callbacks []Callback
+}
+
+func newOptions(opts []Option) options {
+	o := options{rand: deep.NewRand(0)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// supportOnly panics when an option other than the supported ones, named
+// after the functions returning them, is set for the given trainer
+func (o *options) supportOnly(trainer string, supported ...string) {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithFakeQuantization", o.fakeQuantize},
+		{"WithPruning", o.pruning != nil},
+		{"WithDistillation", o.distillation != nil},
+		{"WithConstraint", o.constraints != nil},
+		{"WithClipping", o.clipping != nil},
+		{"WithAdversarialTraining", o.adversarial != nil},
+		{"WithPrivacy", o.privacy != nil},
+		{"WithProximal", o.proximal != nil},
+		{"WithCallbacks", len(o.callbacks) > 0},
+	} {
+		if option.set && !contains(supported, option.name) {
+			panic(fmt.Sprintf("%s is not supported by %s", option.name, trainer))
+		}
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSeed makes the trainer draw random numbers, such as for shuffling
+// examples, from a source seeded with seed
+func WithSeed(seed int64) Option {
+	return func(o *options) { o.rand = deep.NewRand(seed) }
+}
+
+// WithSource makes the trainer draw random numbers from src
+func WithSource(src rand.Source) Option {
+	return func(o *options) { o.rand = rand.New(src) }
+}
+
+// WithFakeQuantization makes the trainer compute gradients through the int8
+// rounding of the weights that deep.Quantize applies with granularity g,
+// while updating the full precision weights (straight-through estimation)
+func WithFakeQuantization(g deep.Granularity) Option {
+	return func(o *options) { o.fakeQuantize, o.granularity = true, g }
+}
+
+// weights returns the weights that gradients are computed with
+func (o *options) weights(n *deep.Neural) [][][]float64 {
+	if o.fakeQuantize {
+		return deep.FakeQuantize(n, o.granularity)
+	}
+	return n.Weights()
+}
+
+// teacher returns the network distilled from, if any
+func (o *options) teacher() *deep.Neural {
+	if o.distillation == nil {
+		return nil
+	}
+	return o.distillation.teacher
+}
+
+// prune applies the pruning schedule, if any, after the given epoch
+func (o *options) prune(n *deep.Neural, epoch int) {
+	if o.pruning != nil {
+		o.pruning.prune(n, epoch)
+	}
+}
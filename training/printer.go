@@ -3,45 +3,133 @@ package training
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	deep "github.com/Maxime2/go-deep"
 )
 
-// StatsPrinter prints training progress
+// StatsPrinter prints training progress. Trainers notify theirs first of
+// their callbacks.
 type StatsPrinter struct {
+	// Verbosity is the number of epochs between the progress lines printed
+	// as a callback, which prints none if it is zero
+	Verbosity int
+
 	w *tabwriter.Writer
+	// teacher is the network distilled from, whose agreement with the
+	// trained network is printed for classifiers
+	teacher *deep.Neural
+	// regularizer of the solver, whose penalty is added to the loss
+	regularizer *Regularizer
+	// clipping of gradients, whose frequency is printed
+	clipping *clipping
+	// privacy of training, whose spent ε is printed
+	privacy *privacy
 }
 
 // NewStatsPrinter creates a StatsPrinter
 func NewStatsPrinter() *StatsPrinter {
-	return &StatsPrinter{tabwriter.NewWriter(os.Stdout, 16, 0, 3, ' ', 0)}
+	return &StatsPrinter{w: tabwriter.NewWriter(os.Stdout, 16, 0, 3, ' ', 0)}
 }
 
 // Init initializes printer
 func (p *StatsPrinter) Init(n *deep.Neural) {
 	fmt.Fprintf(p.w, "Epochs\tElapsed\tLoss (%s)\t", n.Config.Loss)
-	if n.Config.Mode == deep.ModeMultiClass {
-		fmt.Fprintf(p.w, "Accuracy\t\n---\t---\t---\t---\t\n")
-	} else {
-		fmt.Fprintf(p.w, "\n---\t---\t---\t\n")
+	columns := 3
+	switch n.Config.Mode {
+	case deep.ModeMultiClass:
+		fmt.Fprintf(p.w, "Accuracy\t")
+		columns++
+	case deep.ModeSurvival:
+		fmt.Fprintf(p.w, "C-index\t")
+		columns++
+	}
+	if p.agreement(n) {
+		fmt.Fprintf(p.w, "Agreement\t")
+		columns++
+	}
+	if p.clipping != nil {
+		fmt.Fprintf(p.w, "Clipped\t")
+		columns++
 	}
+	if p.privacy != nil {
+		fmt.Fprintf(p.w, "ε (δ=%g)\t", p.privacy.Delta)
+		columns++
+	}
+	fmt.Fprintf(p.w, "\n%s\n", strings.Repeat("---\t", columns))
 }
 
 // PrintProgress prints the current state of training
 func (p *StatsPrinter) PrintProgress(n *deep.Neural, validation Examples, elapsed time.Duration, iteration int) {
-	fmt.Fprintf(p.w, "%d\t%s\t%.*e\t%s\n",
+	m := &Metrics{
+		Elapsed:     elapsed,
+		Validation:  validation,
+		n:           n,
+		teacher:     p.teacher,
+		regularizer: p.regularizer,
+		clipping:    p.clipping,
+		privacy:     p.privacy,
+	}
+	fmt.Fprintf(p.w, "%d\t%s\t%.*e\t%s",
 		iteration,
-		elapsed.String(),
-		n.Config.LossPrecision, crossValidate(n, validation),
+		m.Elapsed.String(),
+		n.Config.LossPrecision, m.Loss(),
 		formatAccuracy(n, validation))
+	if p.agreement(n) {
+		fmt.Fprintf(p.w, "%.2f\t", m.Agreement())
+	}
+	if p.clipping != nil {
+		fmt.Fprintf(p.w, "%.2f%%\t", 100*m.Clipped())
+	}
+	if p.privacy != nil {
+		fmt.Fprintf(p.w, "%.3f\t", m.Epsilon())
+	}
+	fmt.Fprintln(p.w)
 	p.w.Flush()
 }
 
+// OnTrainBegin prints the header
+func (p *StatsPrinter) OnTrainBegin(n *deep.Neural, m *Metrics) {
+	p.Init(n)
+}
+
+// OnEpochBegin does nothing
+func (p *StatsPrinter) OnEpochBegin(n *deep.Neural, epoch int, m *Metrics) {}
+
+// OnBatchEnd does nothing
+func (p *StatsPrinter) OnBatchEnd(n *deep.Neural, epoch, batch int, m *Metrics) bool {
+	return false
+}
+
+// OnEpochEnd prints the progress every Verbosity epochs, if there are
+// validation examples
+func (p *StatsPrinter) OnEpochEnd(n *deep.Neural, epoch int, m *Metrics) bool {
+	if p.Verbosity > 0 && epoch%p.Verbosity == 0 && len(m.Validation) > 0 {
+		p.PrintProgress(n, m.Validation, m.Elapsed, epoch)
+	}
+	return false
+}
+
+// OnTrainEnd does nothing
+func (p *StatsPrinter) OnTrainEnd(n *deep.Neural, epoch int, m *Metrics) {}
+
+// agreement reports whether the agreement of n with the teacher is printed
+func (p *StatsPrinter) agreement(n *deep.Neural) bool {
+	switch n.Config.Mode {
+	case deep.ModeMultiClass, deep.ModeBinary, deep.ModeMultiLabel:
+		return p.teacher != nil
+	}
+	return false
+}
+
 func formatAccuracy(n *deep.Neural, validation Examples) string {
-	if n.Config.Mode == deep.ModeMultiClass {
+	switch n.Config.Mode {
+	case deep.ModeMultiClass:
 		return fmt.Sprintf("%.2f\t", accuracy(n, validation))
+	case deep.ModeSurvival:
+		return fmt.Sprintf("%.2f\t", Concordance(n, validation))
 	}
 	return ""
 }
@@ -58,11 +146,11 @@ func accuracy(n *deep.Neural, validation Examples) float64 {
 }
 
 func crossValidate(n *deep.Neural, validation Examples) float64 {
-	predictions, responses := make([][]float64, len(validation)), make([][]float64, len(validation))
+	predictions := make([][]float64, len(validation))
 	for i := 0; i < len(validation); i++ {
 		predictions[i] = n.Predict(validation[i].Input)
-		responses[i] = validation[i].Response
 	}
+	responses, weights, mask := validation.targets()
 
-	return deep.GetLoss(n.Config.Loss).F(predictions, responses)
+	return deep.GetLoss(n.Config.Loss).F(predictions, responses, weights, mask)
 }
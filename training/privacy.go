@@ -0,0 +1,213 @@
+package training
+
+import (
+	"fmt"
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Privacy configures differentially private training (DP-SGD, Abadi et al.,
+// 2016): the gradient of every example is clipped to a bounded norm, and
+// gaussian noise scaled to that bound is added to the summed gradient of
+// every batch.
+//
+// The privacy spent is accounted for as if every batch were sampled by
+// including each example independently with probability batch size over
+// number of examples (Poisson subsampling), as the analysis of DP-SGD
+// assumes, whereas the trainers draw batches as consecutive slices of a
+// shuffled epoch. The ε reported are thus those of the sampling analyzed,
+// not a guarantee for the batches actually drawn.
+type Privacy struct {
+	// Clip bounds the L2 norm of the gradient of every example
+	Clip float64
+	// NoiseMultiplier is the standard deviation of the noise relative to Clip
+	NoiseMultiplier float64
+	// Delta is the δ that the ε spent is reported for, 1e-5 by default
+	Delta float64
+}
+
+// privacy is the state of differentially private training
+type privacy struct {
+	Privacy
+	accountant *Accountant
+	// sums holds the clipped gradients every worker has summed over a batch
+	sums     [][][][]float64
+	epsilons []float64
+}
+
+// WithPrivacy makes the trainer train n with differential privacy as
+// configured by p, and account for the privacy spent after every epoch. It
+// panics unless Clip is positive and NoiseMultiplier is not negative.
+func WithPrivacy(p Privacy) Option {
+	if !(p.Clip > 0) {
+		panic(fmt.Sprintf("Invalid privacy clip %v - it must be positive", p.Clip))
+	}
+	if !(p.NoiseMultiplier >= 0) {
+		panic(fmt.Sprintf("Invalid noise multiplier %v - it must not be negative", p.NoiseMultiplier))
+	}
+	return func(o *options) {
+		p.Delta = fparam(p.Delta, 1e-5)
+		o.privacy = &privacy{Privacy: p}
+	}
+}
+
+// Privacy returns the ε spent after every epoch of differentially private
+// training so far, along with the δ they hold for
+func (o *options) Privacy() (epsilons []float64, delta float64) {
+	if o.privacy == nil {
+		return nil, 0
+	}
+	return o.privacy.epsilons, o.privacy.Delta
+}
+
+// initPrivacy prepares differentially private training of n on examples in
+// batches of batchSize by workers workers
+func (o *options) initPrivacy(n *deep.Neural, examples Examples, batchSize, workers int) {
+	p := o.privacy
+	if p == nil {
+		return
+	}
+	if _, ok := deep.GetLoss(n.Config.Loss).(deep.BatchLoss); ok {
+		panic(fmt.Sprintf("Differential privacy is not supported with %s loss", n.Config.Loss))
+	}
+	p.accountant = &Accountant{
+		SamplingRate:    math.Min(1, float64(batchSize)/float64(len(examples))),
+		NoiseMultiplier: p.NoiseMultiplier,
+	}
+	p.sums = make([][][][]float64, workers)
+	for w := range p.sums {
+		p.sums[w] = make([][][]float64, len(n.Layers))
+		for i, l := range n.Layers {
+			p.sums[w][i] = make([][]float64, len(l.Neurons))
+			for j, neuron := range l.Neurons {
+				p.sums[w][i][j] = make([]float64, len(neuron.In))
+			}
+		}
+	}
+	p.epsilons = nil
+}
+
+// clipExample clips the gradient that n, the net of worker w, has
+// accumulated for a single example, adds it to the sum of the worker and
+// resets it
+func (o *options) clipExample(n *deep.Neural, w int) {
+	if o.privacy == nil {
+		return
+	}
+	var norm float64
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				norm += s.Gradient * s.Gradient
+			}
+		}
+	}
+	scale := math.Min(1, o.privacy.Clip/math.Sqrt(norm))
+
+	sum := o.privacy.sums[w]
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				sum[i][j][k] += scale * s.Gradient
+				s.Gradient = 0
+			}
+		}
+	}
+}
+
+// sumExamples moves the clipped gradients worker w has summed into n, the net
+// of the worker
+func (o *options) sumExamples(n *deep.Neural, w int) {
+	if o.privacy == nil {
+		return
+	}
+	sum := o.privacy.sums[w]
+	n.SetGradients(sum)
+	for i := range sum {
+		for j := range sum[i] {
+			for k := range sum[i][j] {
+				sum[i][j][k] = 0
+			}
+		}
+	}
+}
+
+// privatize adds gaussian noise to the summed gradients of a batch, leaving
+// out those of pruned weights
+func (o *options) privatize(n *deep.Neural, gradients [][][]float64) {
+	p := o.privacy
+	if p == nil {
+		return
+	}
+	std := p.NoiseMultiplier * p.Clip
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if !s.Pruned {
+					gradients[i][j][k] += std * o.rand.NormFloat64()
+				}
+			}
+		}
+	}
+}
+
+// account accounts for an epoch of differentially private training in steps
+// batches
+func (o *options) account(steps int) {
+	p := o.privacy
+	if p == nil {
+		return
+	}
+	p.accountant.Steps += steps
+	p.epsilons = append(p.epsilons, p.accountant.Epsilon(p.Delta))
+}
+
+// Accountant accounts for the privacy spent by steps of DP-SGD, each of which
+// applies the gaussian mechanism with NoiseMultiplier to a batch sampled with
+// SamplingRate, by their Rényi differential privacy (Mironov et al., 2019).
+// The bound is that of the sampled gaussian mechanism, where each example is
+// in a batch independently with probability SamplingRate.
+type Accountant struct {
+	SamplingRate, NoiseMultiplier float64
+	Steps                         int
+}
+
+// Epsilon returns the ε for which the steps taken so far are (ε, δ)
+// differentially private
+func (a *Accountant) Epsilon(delta float64) float64 {
+	epsilon := math.Inf(1)
+	for order := 2; order <= 256; order++ {
+		rdp := float64(a.Steps) * a.rdp(order)
+		epsilon = math.Min(epsilon, rdp+math.Log(1/delta)/float64(order-1))
+	}
+	return epsilon
+}
+
+// rdp is the Rényi differential privacy of a single step at an integer order
+func (a *Accountant) rdp(order int) float64 {
+	q, sigma := a.SamplingRate, a.NoiseMultiplier
+	if q == 0 {
+		return 0
+	}
+	if q >= 1 {
+		return float64(order) / (2 * sigma * sigma)
+	}
+
+	// log Σ_k C(order, k) (1-q)^(order-k) q^k exp((k²-k) / 2σ²)
+	alpha := float64(order)
+	terms := make([]float64, order+1)
+	for k := range terms {
+		kf := float64(k)
+		lc, _ := math.Lgamma(alpha + 1)
+		lk, _ := math.Lgamma(kf + 1)
+		lr, _ := math.Lgamma(alpha - kf + 1)
+		terms[k] = lc - lk - lr + (alpha-kf)*math.Log1p(-q) + kf*math.Log(q) + (kf*kf-kf)/(2*sigma*sigma)
+	}
+	max := deep.Max(terms)
+	var sum float64
+	for _, t := range terms {
+		sum += math.Exp(t - max)
+	}
+	return (max + math.Log(sum)) / (alpha - 1)
+}
@@ -0,0 +1,104 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Accountant(t *testing.T) {
+	// Without subsampling every step is a gaussian mechanism of order/2σ²
+	a := &Accountant{SamplingRate: 1, NoiseMultiplier: 2, Steps: 10}
+	expected := math.Inf(1)
+	for order := 2; order <= 256; order++ {
+		expected = math.Min(expected, 10*float64(order)/8+math.Log(1e5)/float64(order-1))
+	}
+	assert.InDelta(t, expected, a.Epsilon(1e-5), 1e-9)
+
+	// MNIST with 60000 examples, batches of 256, σ = 1.1, 60 epochs and
+	// δ = 1e-5 spends ε ≈ 3 (Abadi et al., 2016)
+	a = &Accountant{SamplingRate: 256. / 60000, NoiseMultiplier: 1.1, Steps: 60 * 60000 / 256}
+	assert.InDelta(t, 3, a.Epsilon(1e-5), 0.2)
+
+	// Privacy is spent with every step, and more slowly with more noise
+	fewer := &Accountant{SamplingRate: a.SamplingRate, NoiseMultiplier: 1.1, Steps: a.Steps / 2}
+	noisier := &Accountant{SamplingRate: a.SamplingRate, NoiseMultiplier: 2, Steps: a.Steps}
+	assert.True(t, fewer.Epsilon(1e-5) < a.Epsilon(1e-5))
+	assert.True(t, noisier.Epsilon(1e-5) < a.Epsilon(1e-5))
+}
+
+func Test_ClipExample(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{3, 2}, Mode: deep.ModeMultiClass, Bias: true, Seed: 1})
+	o := newOptions([]Option{WithPrivacy(Privacy{Clip: 0.5})})
+	o.initPrivacy(n, make(Examples, 10), 5, 1)
+
+	for _, e := range blobs(2, 1) {
+		n.Forward(e.Input)
+		deltas := make([]float64, 2)
+		outputDeltas(n, e, deltas)
+		n.BackwardDeltas(deltas)
+		o.clipExample(n, 0)
+		assert.Equal(t, 0., norm(n.Gradients()))
+	}
+	o.sumExamples(n, 0)
+	assert.True(t, norm(n.Gradients()) <= 1+1e-12)
+	assert.Equal(t, 0., norm(o.privacy.sums[0]))
+}
+
+func norm(gradients [][][]float64) float64 {
+	var sum float64
+	for _, l := range gradients {
+		for _, neuron := range l {
+			for _, g := range neuron {
+				sum += g * g
+			}
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+func Test_PrivateTraining(t *testing.T) {
+	train, test := blobs(400, 1), blobs(200, 2)
+	config := &deep.Config{
+		Inputs:     2,
+		Layout:     []int{8, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}
+
+	// Neither clipped nor noised, the first epoch is that of plain training
+	for _, trainer := range []func(opts ...Option) Trainer{
+		func(opts ...Option) Trainer {
+			return NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 20, 2, append(opts, WithSeed(1))...)
+		},
+		func(opts ...Option) Trainer {
+			return NewTrainer(NewAdam(0.01, 0, 0, 0), 0, append(opts, WithSeed(1))...)
+		},
+	} {
+		// The online trainer shuffles the examples in place
+		plain, private := deep.NewNeural(config), deep.NewNeural(config)
+		trainer().Train(plain, append(Examples{}, train...), nil, 1)
+		trainer(WithPrivacy(Privacy{Clip: 1e9})).Train(private, append(Examples{}, train...), nil, 1)
+		assert.Equal(t, plain.Weights(), private.Weights())
+	}
+
+	n := deep.NewNeural(config)
+	trainer := NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 40, 2, WithSeed(1), WithPrivacy(Privacy{Clip: 1, NoiseMultiplier: 1}))
+	trainer.Train(n, train, nil, 20)
+	assert.True(t, accuracy(n, test) > 0.9)
+
+	epsilons, delta := trainer.Privacy()
+	assert.Equal(t, 1e-5, delta)
+	assert.Len(t, epsilons, 20)
+	for i := 1; i < len(epsilons); i++ {
+		assert.True(t, epsilons[i] > epsilons[i-1])
+	}
+	assert.InDelta(t, (&Accountant{SamplingRate: 0.1, NoiseMultiplier: 1, Steps: 200}).Epsilon(1e-5), epsilons[19], 1e-12)
+
+	assert.Panics(t, func() { WithPrivacy(Privacy{NoiseMultiplier: 1}) })
+	assert.Panics(t, func() { WithPrivacy(Privacy{Clip: 1, NoiseMultiplier: -1}) })
+}
@@ -0,0 +1,38 @@
+package training
+
+import deep "github.com/Maxime2/go-deep"
+
+// proximal keeps the weights near an anchor
+type proximal struct {
+	anchor [][][]float64
+	mu     float64
+}
+
+// WithProximal makes the trainer add the gradient of the proximal term
+// mu/2 ‖w - anchor‖² to the gradients of every example, which keeps the
+// weights near anchor, such as the global weights in FedProx (Li et al.,
+// 2020). As the gradients of a batch are summed over its examples, so is the
+// term, which weighs the same whatever the batch size.
+func WithProximal(anchor [][][]float64, mu float64) Option {
+	return func(o *options) { o.proximal = &proximal{anchor: anchor, mu: mu} }
+}
+
+// approach adds the gradient of the proximal term for the given number of
+// examples to the gradients of the weights of n, leaving out those of pruned
+// weights
+func (o *options) approach(n *deep.Neural, gradients [][][]float64, examples int) {
+	p := o.proximal
+	if p == nil {
+		return
+	}
+	mu := p.mu * float64(examples)
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				if !s.Pruned {
+					gradients[i][j][k] += mu * (s.Weight - p.anchor[i][j][k])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package training
+
+import (
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Proximal(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{3, 2}, Mode: deep.ModeMultiClass, Bias: true, Seed: 1})
+	anchor := n.Weights()
+	n.Layers[0].Neurons[0].In[0].Weight += 2
+	n.Layers[1].Neurons[1].In[1].Weight -= 1
+	n.Layers[1].Neurons[1].In[1].Pruned = true
+
+	gradients := n.Gradients()
+	o := newOptions([]Option{WithProximal(anchor, 0.5)})
+	o.approach(n, gradients, 1)
+	assert.Equal(t, 1., gradients[0][0][0])
+	assert.Equal(t, 0., gradients[1][1][1])
+	assert.Equal(t, 1., norm(gradients))
+
+	// The term is added for every example
+	o.approach(n, gradients, 2)
+	assert.Equal(t, 3., gradients[0][0][0])
+
+	// Without the option gradients are left alone
+	o = newOptions(nil)
+	o.approach(n, gradients, 1)
+	assert.Equal(t, 3., norm(gradients))
+
+	// Anchored at the initial weights, training stays closer to them
+	config := &deep.Config{Inputs: 2, Layout: []int{8, 2}, Activation: deep.ActivationTanh, Mode: deep.ModeMultiClass, Bias: true, Seed: 1}
+	initial := deep.NewNeural(config).Weights()
+	distance := func(opts ...Option) float64 {
+		n := deep.NewNeural(config)
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 20, 1, append(opts, WithSeed(1))...).Train(n, blobs(200, 1), nil, 20)
+		return norm(difference(n.Weights(), initial))
+	}
+	assert.True(t, distance(WithProximal(initial, 1)) < distance())
+}
+
+func difference(a, b [][][]float64) [][][]float64 {
+	d := make([][][]float64, len(a))
+	for i := range a {
+		d[i] = make([][]float64, len(a[i]))
+		for j := range a[i] {
+			d[i][j] = make([]float64, len(a[i][j]))
+			for k := range a[i][j] {
+				d[i][j][k] = a[i][j][k] - b[i][j][k]
+			}
+		}
+	}
+	return d
+}
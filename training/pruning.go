@@ -0,0 +1,58 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Pruning gradually prunes the smallest-magnitude weights of a network while
+// it trains, raising its sparsity from Initial to Final between the Begin and
+// End epochs along a cubic schedule (Zhu & Gupta, 2017)
+type Pruning struct {
+	Initial, Final float64
+	Begin, End     int
+	// Frequency is the number of epochs between pruning steps, 1 by default
+	Frequency int
+	// Global ranks the weights of all layers together instead of per layer
+	Global bool
+	// Neurons prunes whole neurons instead, the sparsity being the fraction
+	// of the neurons of each hidden layer masked by deep.MaskNeurons, which
+	// deep.RemoveMaskedNeurons removes once training is over
+	Neurons bool
+}
+
+// Sparsity is the target sparsity after the given epoch
+func (p Pruning) Sparsity(epoch int) float64 {
+	switch {
+	case epoch < p.Begin:
+		return 0
+	case epoch >= p.End:
+		return p.Final
+	}
+	progress := float64(epoch-p.Begin) / float64(p.End-p.Begin)
+	return p.Final + (p.Initial-p.Final)*math.Pow(1-progress, 3)
+}
+
+func (p Pruning) prune(n *deep.Neural, epoch int) {
+	if epoch < p.Begin || (epoch-p.Begin)%iparam(p.Frequency, 1) != 0 {
+		return
+	}
+	switch {
+	case p.Neurons:
+		for i, l := range n.Layers[:len(n.Layers)-1] {
+			count := int(math.Round(p.Sparsity(epoch) * float64(len(l.Neurons))))
+			deep.MaskNeurons(n, i, min(count, len(l.Neurons)-1))
+		}
+	case p.Global:
+		deep.PruneGlobal(n, p.Sparsity(epoch))
+	default:
+		deep.PruneLayers(n, p.Sparsity(epoch))
+	}
+}
+
+// WithPruning makes the trainer prune the network after every epoch
+// according to p. Pruned weights are no longer updated.
+func WithPruning(p Pruning) Option {
+	return func(o *options) { o.pruning = &p }
+}
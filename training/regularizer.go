@@ -0,0 +1,109 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Regularizer penalizes the magnitude of the weights updated by a solver.
+// Solvers embed it, so that it is configured per solver:
+//
+//	solver := NewAdam(0.001, 0, 0, 0)
+//	solver.Regularizer = Regularizer{WeightDecay: 0.01, ExemptBias: true}
+type Regularizer struct {
+	// L1 and L2 add L1·|w| + L2/2·w² to the loss of every example, which
+	// together make an elastic net
+	L1, L2 float64
+	// WeightDecay shrinks weights by the learning rate times WeightDecay·w at
+	// every update, decoupled from the gradient as in AdamW
+	WeightDecay float64
+	// ExemptBias leaves bias weights unpenalized
+	ExemptBias bool
+
+	bias []bool
+	// Number of examples whose gradients the next updates sum
+	examples int
+}
+
+// regularized is implemented by solvers embedding a Regularizer
+type regularized interface {
+	regularizer() *Regularizer
+}
+
+func (r *Regularizer) regularizer() *Regularizer {
+	return r
+}
+
+// regularizerOf returns the Regularizer of solver, if any
+func regularizerOf(solver Solver) *Regularizer {
+	if s, ok := solver.(regularized); ok {
+		return s.regularizer()
+	}
+	return nil
+}
+
+// initRegularization tells the regularizer of solver, if any, which of the
+// weights of n, in the order they are updated, are biases
+func initRegularization(solver Solver, n *deep.Neural) {
+	r := regularizerOf(solver)
+	if r == nil {
+		return
+	}
+	r.bias = make([]bool, 0, n.NumWeights())
+	r.examples = 1
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				r.bias = append(r.bias, s.IsBias)
+			}
+		}
+	}
+}
+
+// regularizeExamples tells the regularizer of solver, if any, that the
+// gradients of the next updates are summed over the given number of examples,
+// so that the penalties are added as many times
+func regularizeExamples(solver Solver, examples int) {
+	if r := regularizerOf(solver); r != nil {
+		r.examples = examples
+	}
+}
+
+// exempt reports whether weight idx is left unpenalized
+func (r *Regularizer) exempt(idx int) bool {
+	return r.ExemptBias && idx < len(r.bias) && r.bias[idx]
+}
+
+// penalize adds the gradient of the L1 and L2 penalties of weight idx for
+// every example of the update
+func (r *Regularizer) penalize(value, gradient float64, idx int) float64 {
+	if r.exempt(idx) {
+		return gradient
+	}
+	examples := float64(iparam(r.examples, 1))
+	return gradient + examples*(r.L1*deep.Sgn(value)+r.L2*value)
+}
+
+// shrink returns the decoupled weight decay of weight idx at learning rate lr
+func (r *Regularizer) shrink(value, lr float64, idx int) float64 {
+	if r.exempt(idx) {
+		return 0
+	}
+	return -lr * r.WeightDecay * value
+}
+
+// Penalty is the L1 and L2 penalty of the weights of n
+func (r *Regularizer) Penalty(n *deep.Neural) float64 {
+	var penalty float64
+	for _, l := range n.Layers {
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if !(r.ExemptBias && s.IsBias) {
+					penalty += r.L1*math.Abs(s.Weight) + r.L2/2*s.Weight*s.Weight
+				}
+			}
+		}
+	}
+	return penalty
+}
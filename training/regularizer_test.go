@@ -0,0 +1,115 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Regularizer(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 2,
+		Layout: []int{1},
+		Mode:   deep.ModeBinary,
+		Bias:   true,
+		Seed:   1,
+	})
+	n.ApplyWeights([][][]float64{{{2, -1, 0.5}}})
+
+	sgd := NewSGD(0.1, 0, 0, false)
+	sgd.Regularizer = Regularizer{L1: 0.1, L2: 0.2, ExemptBias: true}
+	sgd.Init(n.NumWeights())
+	initRegularization(sgd, n)
+	assert.InDelta(t, -0.1*(0.1+0.2*2), sgd.Update(2, 0, 0, 1, 0), 1e-12)
+	assert.InDelta(t, -0.1*(-0.1+0.2*-1), sgd.Update(-1, 0, 0, 1, 1), 1e-12)
+	assert.Equal(t, 0.0, sgd.Update(0.5, 0, 0, 1, 2))
+	assert.InDelta(t, 0.1*(2+1)+0.1*(4+1), sgd.Penalty(n), 1e-12)
+
+	sgd.ExemptBias = false
+	assert.InDelta(t, 0.1*(2+1+0.5)+0.1*(4+1+0.25), sgd.Penalty(n), 1e-12)
+
+	// Decoupled weight decay does not go through the adaptive step
+	adam := NewAdam(0.01, 0, 0, 0)
+	adam.Regularizer = Regularizer{WeightDecay: 0.5, ExemptBias: true}
+	adam.Init(n.NumWeights())
+	initRegularization(adam, n)
+	assert.InDelta(t, -0.01*0.5*2, adam.Update(2, 0, 0, 1, 0), 1e-12)
+	assert.Equal(t, 0.0, adam.Update(0.5, 0, 0, 1, 2))
+	assert.Equal(t, 0.0, adam.Penalty(n))
+}
+
+func Test_RegularizerBatchSize(t *testing.T) {
+	// Examples leaving the loss out, so that only the penalties update weights
+	data := make(Examples, 10)
+	for i := range data {
+		data[i] = Example{Input: []float64{0, 0}, Response: []float64{1}}.Weighted(0)
+	}
+
+	decayed := func(batchSize int) [][][]float64 {
+		n := deep.NewNeural(&deep.Config{
+			Inputs: 2,
+			Layout: []int{1},
+			Mode:   deep.ModeRegression,
+			Bias:   true,
+			Seed:   1,
+		})
+		solver := NewSGD(0.01, 0, 0, false)
+		solver.Regularizer = Regularizer{L2: 0.1}
+		NewBatchTrainer(solver, 0, batchSize, 1, WithSeed(1)).Train(n, data, nil, 1)
+		return n.Weights()
+	}
+
+	// An epoch decays the weights as much whatever the batch size, up to
+	// terms of second order in the learning rate
+	online, batch := decayed(1), decayed(len(data))
+	for i, l := range online {
+		for j, ws := range l {
+			for k, w := range ws {
+				assert.InEpsilon(t, w, batch[i][j][k], 1e-4)
+			}
+		}
+	}
+}
+
+func Test_WeightDecay(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+
+	train := func(r Regularizer) (norm float64, acc float64) {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     len(data[0].Input),
+			Layout:     []int{8, 3},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Init:       &deep.Initializer{Kind: "glorot_uniform"},
+			Bias:       true,
+			Seed:       1,
+		})
+		solver := NewAdam(0.01, 0, 0, 0)
+		solver.Regularizer = r
+		NewBatchTrainer(solver, 0, 16, 2, WithSeed(1)).Train(n, data, nil, 50)
+		for _, l := range n.Layers {
+			for _, neuron := range l.Neurons {
+				for _, s := range neuron.In {
+					if !s.IsBias {
+						norm += s.Weight * s.Weight
+					}
+				}
+			}
+		}
+		return math.Sqrt(norm), accuracy(n, data)
+	}
+
+	plain, _ := train(Regularizer{})
+	for _, r := range []Regularizer{
+		{L2: 0.01, ExemptBias: true},
+		{L1: 0.01, L2: 0.01},
+		{WeightDecay: 0.5, ExemptBias: true},
+	} {
+		norm, acc := train(r)
+		assert.True(t, norm < plain, "%+v: norm %f vs %f", r, norm, plain)
+		assert.True(t, acc > 0.9, "%+v: accuracy %f", r, acc)
+	}
+}
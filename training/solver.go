@@ -15,6 +15,8 @@ type SGD struct {
 	momentum float64
 	nesterov bool
 	moments  []float64
+
+	Regularizer
 }
 
 // NewSGD returns a new SGD solver
@@ -34,8 +36,9 @@ func (o *SGD) Init(size int) {
 
 // Update returns the update for a given weight
 func (o *SGD) Update(value, gradient, in float64, iteration, idx int) float64 {
-	lr := o.lr / (1 + o.decay*float64(iteration))
-	lr = lr / (1 + lr * in * in)
+	gradient = o.penalize(value, gradient, idx)
+	scheduled := o.lr / (1 + o.decay*float64(iteration))
+	lr := scheduled / (1 + scheduled*in*in)
 
 	o.moments[idx] = o.momentum*o.moments[idx] - lr*gradient
 
@@ -43,7 +46,7 @@ func (o *SGD) Update(value, gradient, in float64, iteration, idx int) float64 {
 		o.moments[idx] = o.momentum*o.moments[idx] - lr*gradient
 	}
 
-	return o.moments[idx]
+	return o.moments[idx] + o.shrink(value, scheduled, idx)
 }
 
 // Adam is an Adam solver
@@ -54,6 +57,8 @@ type Adam struct {
 	epsilon float64
 
 	v, m []float64
+
+	Regularizer
 }
 
 // NewAdam returns a new Adam solver
@@ -73,12 +78,13 @@ func (o *Adam) Init(size int) {
 
 // Update returns the update for a given weight
 func (o *Adam) Update(value, gradient, in float64, t, idx int) float64 {
+	gradient = o.penalize(value, gradient, idx)
 	lrt := o.lr * (math.Sqrt(1.0 - math.Pow(o.beta2, float64(t)))) /
 		(1.0 - math.Pow(o.beta, float64(t)))
 	o.m[idx] = o.beta*o.m[idx] + (1.0-o.beta)*gradient
 	o.v[idx] = o.beta2*o.v[idx] + (1.0-o.beta2)*math.Pow(gradient, 2.0)
 
-	return -lrt * (o.m[idx] / (math.Sqrt(o.v[idx]) + o.epsilon))
+	return -lrt*(o.m[idx]/(math.Sqrt(o.v[idx])+o.epsilon)) + o.shrink(value, o.lr, idx)
 }
 
 func fparam(val, fallback float64) float64 {
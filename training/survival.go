@@ -0,0 +1,57 @@
+package training
+
+import deep "github.com/Maxime2/go-deep"
+
+// NewSurvivalExample returns an example for ModeSurvival, observed until time t.
+// The response packs the time with an event indicator, which is false
+// when the observation was censored at t.
+func NewSurvivalExample(input []float64, t float64, event bool) Example {
+	var e float64
+	if event {
+		e = 1
+	}
+	return Example{Input: input, Response: []float64{t, e}}
+}
+
+// Time is the observed time of a survival example
+func (e Example) Time() float64 {
+	return e.Response[0]
+}
+
+// Event reports whether the event of a survival example was observed
+func (e Example) Event() bool {
+	return e.Response[1] != 0
+}
+
+// Concordance is Harrell's concordance index of the risk scores predicted
+// by n: the fraction of comparable pairs whose predicted risks are ordered
+// like their observed times, counting tied risks as half concordant
+func Concordance(n *deep.Neural, examples Examples) float64 {
+	risk := make([]float64, len(examples))
+	for i, e := range examples {
+		risk[i] = n.Predict(e.Input)[0]
+	}
+
+	var concordant, comparable float64
+	for i, a := range examples {
+		if !a.Event() {
+			continue
+		}
+		for j, b := range examples {
+			if a.Time() >= b.Time() {
+				continue
+			}
+			comparable++
+			switch {
+			case risk[i] > risk[j]:
+				concordant++
+			case risk[i] == risk[j]:
+				concordant += 0.5
+			}
+		}
+	}
+	if comparable == 0 {
+		return 0
+	}
+	return concordant / comparable
+}
@@ -0,0 +1,61 @@
+package training
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Survival(t *testing.T) {
+	rand.Seed(0)
+
+	var data Examples
+	for i := 0; i < 300; i++ {
+		x := []float64{rand.Float64(), rand.Float64()}
+		hazard := math.Exp(2*x[0] - x[1])
+		time := -math.Log(rand.Float64()) / hazard
+		data = append(data, NewSurvivalExample(x, time, rand.Float64() > 0.3))
+	}
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeSurvival,
+		Weight:     deep.NewUniform(0.5, 0),
+		Bias:       true,
+	})
+	assert.Equal(t, deep.LossCox, n.Config.Loss)
+
+	before := crossValidate(n, data)
+	trainer := NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 100, 2)
+	trainer.Train(n, data, nil, 200)
+
+	assert.True(t, crossValidate(n, data) < before)
+	assert.True(t, Concordance(n, data) > 0.65)
+
+	// The partial likelihood of a single example has no gradient
+	assert.Panics(t, func() { NewTrainer(NewAdam(0.01, 0, 0, 0), 0).Train(n, data, nil, 1) })
+}
+
+func Test_Concordance(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 1,
+		Layout: []int{1},
+		Mode:   deep.ModeSurvival,
+	})
+	n.Layers[0].Neurons[0].In[0].Weight = 1
+
+	data := Examples{
+		NewSurvivalExample([]float64{3}, 1, true),
+		NewSurvivalExample([]float64{2}, 2, true),
+		NewSurvivalExample([]float64{1}, 3, false),
+	}
+	assert.Equal(t, 1.0, Concordance(n, data))
+
+	n.Layers[0].Neurons[0].In[0].Weight = -1
+	assert.Equal(t, 0.0, Concordance(n, data))
+}
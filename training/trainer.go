@@ -1,8 +1,8 @@
 package training
 
 import (
+	"fmt"
 	"math"
-	"time"
 
 	deep "github.com/Maxime2/go-deep"
 )
@@ -15,14 +15,16 @@ type Trainer interface {
 // OnlineTrainer is a basic, online network trainer
 type OnlineTrainer struct {
 	*internal
+	options
 	solver    Solver
 	printer   *StatsPrinter
 	verbosity int
 }
 
 // NewTrainer creates a new trainer
-func NewTrainer(solver Solver, verbosity int) *OnlineTrainer {
+func NewTrainer(solver Solver, verbosity int, opts ...Option) *OnlineTrainer {
 	return &OnlineTrainer{
+		options:   newOptions(opts),
 		solver:    solver,
 		printer:   NewStatsPrinter(),
 		verbosity: verbosity,
@@ -30,75 +32,153 @@ func NewTrainer(solver Solver, verbosity int) *OnlineTrainer {
 }
 
 type internal struct {
-	deltas [][]float64
+	deltas    []float64
+	gradients [][][]float64
 }
 
 func newTraining(layers []*deep.Layer) *internal {
-	deltas := make([][]float64, len(layers))
+	deltas := make([]float64, len(layers[len(layers)-1].Neurons))
+	gradients := make([][][]float64, len(layers))
 	for i, l := range layers {
-		deltas[i] = make([]float64, len(l.Neurons)+1)
+		gradients[i] = make([][]float64, len(l.Neurons))
+		for j, n := range l.Neurons {
+			gradients[i][j] = make([]float64, len(n.In))
+		}
 	}
 	return &internal{
-		deltas: deltas,
+		deltas:    deltas,
+		gradients: gradients,
 	}
 }
 
-// Train trains n
+// Train trains n. It panics with a loss which couples the examples of a
+// batch, that of survival mode, whose gradient on a single example is zero.
 func (t *OnlineTrainer) Train(n *deep.Neural, examples, validation Examples, iterations int) {
+	if coupled(n) {
+		panic(fmt.Sprintf("Online training is not supported with %s loss", n.Config.Loss))
+	}
 	t.internal = newTraining(n.Layers)
 
 	//train := make(Examples, len(examples))
 	//copy(train, examples)
 
-	t.printer.Init(n)
+	examples = t.teach(n, examples)
+
+	t.printer.teacher = t.teacher()
 	t.solver.Init(n.NumWeights())
+	initRegularization(t.solver, n)
+	t.printer.regularizer = regularizerOf(t.solver)
+	t.initClipping()
+	t.printer.clipping = t.clipping
+	t.initPrivacy(n, examples, 1, 1)
+	t.printer.privacy = t.privacy
+	t.printer.Verbosity = t.verbosity
 
-	ts := time.Now()
+	p := t.begin(n, validation, t.printer)
 	for i := 1; i <= iterations; i++ {
-		examples.Shuffle()
-		for j := 0; j < len(examples); j++ {
-			t.learn(n, examples[j], i)
+		p.epochBegin(n, i)
+		examples.ShuffleWith(t.rand)
+		var j int
+		for j < len(examples) {
+			t.learn(n, t.perturb(n, examples[j:j+1])[0], i)
+			j++
+			if p.batchEnd(n, j) {
+				break
+			}
 		}
-		if t.verbosity > 0 && i%t.verbosity == 0 && len(validation) > 0 {
-			t.printer.PrintProgress(n, validation, time.Since(ts), i)
+		t.account(j)
+		t.prune(n, i)
+		if p.epochEnd(n) {
+			break
 		}
 	}
+	p.trainEnd(n)
 }
 
 func (t *OnlineTrainer) learn(n *deep.Neural, e Example, it int) {
-	n.Forward(e.Input)
-	t.calculateDeltas(n, e.Response)
+	if t.fakeQuantize {
+		weights := n.Weights()
+		n.ApplyWeights(t.weights(n))
+		n.Forward(e.Input)
+		t.calculateDeltas(n, e)
+		n.ApplyWeights(weights)
+	} else {
+		n.Forward(e.Input)
+		t.calculateDeltas(n, e)
+	}
 	t.update(n, it)
 }
 
-func (t *OnlineTrainer) calculateDeltas(n *deep.Neural, ideal []float64) {
-	for i, neuron := range n.Layers[len(n.Layers)-1].Neurons {
-		t.deltas[len(n.Layers)-1][i] = deep.GetLoss(n.Config.Loss).Df(
-			neuron.Value,
-			ideal[i],
-			neuron.DActivate(neuron.Value))
-	}
+func (t *OnlineTrainer) calculateDeltas(n *deep.Neural, e Example) {
+	outputDeltas(n, e, t.deltas)
+	t.distill(n, e, t.deltas)
 
-	for i := len(n.Layers) - 2; i >= 0; i-- {
-		for j, neuron := range n.Layers[i].Neurons {
-			var sum float64
-			for k, s := range neuron.Out {
-				sum += s.Weight * t.deltas[i+1][k]
-			}
-			if !math.IsNaN(neuron.DActivate(neuron.Value) * sum) {
-				t.deltas[i][j] = neuron.DActivate(neuron.Value) * sum
+	n.BackwardDeltas(t.deltas)
+}
+
+// coupled reports whether the loss of n compares every example with the
+// others of its batch, as the Cox partial likelihood does, so that it has no
+// gradient on an example alone. Other batch losses, such as that of mixture
+// density networks, are defined per example.
+func coupled(n *deep.Neural) bool {
+	_, ok := deep.GetLoss(n.Config.Loss).(deep.Cox)
+	return ok
+}
+
+// outputDeltas sets deltas to the derivatives of the loss of n on e, after a
+// forward pass of its input, with respect to the inputs of the output
+// activations. Losses which couple the examples of a batch see a batch of e.
+func outputDeltas(n *deep.Neural, e Example, deltas []float64) {
+	loss := deep.GetLoss(n.Config.Loss)
+	if bl, ok := loss.(deep.BatchLoss); ok {
+		out := n.Layers[len(n.Layers)-1].Neurons
+		estimate := make([]float64, len(out))
+		for i, neuron := range out {
+			estimate[i] = neuron.Value
+		}
+		ideal, weights, mask := Examples{e}.targets()
+		grad := bl.BatchDf([][]float64{estimate}, ideal, weights, mask)[0]
+		for i, neuron := range out {
+			deltas[i] = grad[i] * neuron.DActivate(neuron.Value)
+		}
+	} else {
+		for i, neuron := range n.Layers[len(n.Layers)-1].Neurons {
+			deltas[i] = 0
+			if e.observed(i) {
+				deltas[i] = e.EffectiveWeight() * loss.Df(
+					neuron.Value,
+					e.Response[i],
+					neuron.DActivate(neuron.Value))
 			}
 		}
 	}
 }
 
 func (t *OnlineTrainer) update(n *deep.Neural, it int) {
+	t.clipExample(n, 0)
+	t.sumExamples(n, 0)
+	for i, l := range n.Layers {
+		for j := range l.Neurons {
+			for k, s := range l.Neurons[j].In {
+				t.gradients[i][j][k] = s.Gradient
+				s.Gradient = 0
+			}
+		}
+	}
+	t.privatize(n, t.gradients)
+	t.approach(n, t.gradients, 1)
+	t.clip(n, t.gradients)
+
 	var idx int
 	for i, l := range n.Layers {
 		for j := range l.Neurons {
 			for k := range l.Neurons[j].In {
+				if l.Neurons[j].In[k].Pruned {
+					idx++
+					continue
+				}
 				update := t.solver.Update(l.Neurons[j].In[k].Weight,
-					t.deltas[i][j]*l.Neurons[j].In[k].In,
+					t.gradients[i][j][k],
 					l.Neurons[j].In[k].In,
 					it,
 					idx)
@@ -109,4 +189,5 @@ func (t *OnlineTrainer) update(n *deep.Neural, it int) {
 			}
 		}
 	}
+	t.constrain(n)
 }
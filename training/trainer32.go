@@ -0,0 +1,159 @@
+package training
+
+import (
+	"math"
+	"time"
+
+	deep "github.com/Maxime2/go-deep"
+)
+
+// Trainer32 trains single precision networks with mini-batch gradient descent
+type Trainer32 struct {
+	options
+	solver    Solver
+	printer   *StatsPrinter
+	verbosity int
+	batchSize int
+}
+
+// NewTrainer32 returns a Trainer32. Of the options, only the seed is
+// supported; the others panic.
+func NewTrainer32(solver Solver, verbosity, batchSize int, opts ...Option) *Trainer32 {
+	o := newOptions(opts)
+	o.supportOnly("a single precision trainer")
+	return &Trainer32{
+		options:   o,
+		solver:    solver,
+		printer:   NewStatsPrinter(),
+		verbosity: verbosity,
+		batchSize: iparam(batchSize, 1),
+	}
+}
+
+// example32 is an example along with its single precision input
+type example32 struct {
+	Example
+	input []float32
+}
+
+// Train trains n
+func (t *Trainer32) Train(n *deep.Neural32, examples, validation Examples, iterations int) {
+	train := make([]example32, len(examples))
+	for i, e := range examples {
+		train[i] = example32{Example: e, input: toFloat32(e.Input)}
+	}
+	grad := make([][][]float32, len(n.Weights))
+	for i, l := range n.Weights {
+		grad[i] = make([][]float32, len(l))
+		for j, ws := range l {
+			grad[i][j] = make([]float32, len(ws))
+		}
+	}
+
+	t.printer.Init(n.Float64())
+	t.solver.Init(n.NumWeights())
+	initRegularization(t.solver, n.Float64())
+	t.printer.regularizer = regularizerOf(t.solver)
+
+	ts := time.Now()
+	for it := 1; it <= iterations; it++ {
+		for i := range train {
+			j := t.rand.Intn(i + 1)
+			train[i], train[j] = train[j], train[i]
+		}
+
+		for lo := 0; lo < len(train); lo += t.batchSize {
+			b := train[lo:min(lo+t.batchSize, len(train))]
+			grads := t.batchGradients(n, b)
+			for i, e := range b {
+				n.Forward(e.input)
+				var g []float64
+				if grads != nil {
+					g = grads[i]
+				}
+				n.Backward(outputDeltas32(n, e.Example, g), grad)
+			}
+			regularizeExamples(t.solver, len(b))
+			t.update(n, grad, it)
+		}
+
+		if t.verbosity > 0 && it%t.verbosity == 0 && len(validation) > 0 {
+			t.printer.PrintProgress(n.Float64(), validation, time.Since(ts), it)
+		}
+	}
+}
+
+// batchGradients returns the gradient of a BatchLoss with respect to the outputs
+// of every example in b, or nil when the loss of n decomposes per example
+func (t *Trainer32) batchGradients(n *deep.Neural32, b []example32) [][]float64 {
+	loss, ok := deep.GetLoss(n.Config.Loss).(deep.BatchLoss)
+	if !ok {
+		return nil
+	}
+	estimates, batch := make([][]float64, len(b)), make(Examples, len(b))
+	for i, e := range b {
+		estimates[i] = toFloat64(n.Predict(e.input))
+		batch[i] = e.Example
+	}
+	ideal, weights, mask := batch.targets()
+	return loss.BatchDf(estimates, ideal, weights, mask)
+}
+
+// outputDeltas32 returns the deltas of the output layer of n for e, given the
+// gradient of a batch loss with respect to its outputs if there is one
+func outputDeltas32(n *deep.Neural32, e Example, grad []float64) []float32 {
+	loss := deep.GetLoss(n.Config.Loss)
+	act := n.OutputActivation()
+	deltas := make([]float32, len(n.Outputs()))
+	for i, v := range n.Outputs() {
+		y := float64(v)
+		switch {
+		case grad != nil:
+			deltas[i] = float32(grad[i] * act.Df(y))
+		case e.observed(i):
+			deltas[i] = float32(e.EffectiveWeight() * loss.Df(y, e.Response[i], act.Df(y)))
+		}
+	}
+	return deltas
+}
+
+func (t *Trainer32) update(n *deep.Neural32, grad [][][]float32, it int) {
+	var idx int
+	for i, l := range n.Weights {
+		for j, ws := range l {
+			for k := range ws {
+				if n.Pruned != nil && n.Pruned[i][j][k] {
+					grad[i][j][k] = 0
+					idx++
+					continue
+				}
+				update := t.solver.Update(float64(ws[k]),
+					float64(grad[i][j][k]),
+					float64(n.In(i, j, k)),
+					it,
+					idx)
+				if !math.IsNaN(float64(ws[k]) + update) {
+					ws[k] += float32(update)
+				}
+				grad[i][j][k] = 0
+				idx++
+			}
+		}
+	}
+}
+
+func toFloat32(xx []float64) []float32 {
+	out := make([]float32, len(xx))
+	for i, x := range xx {
+		out[i] = float32(x)
+	}
+	return out
+}
+
+func toFloat64(xx []float32) []float64 {
+	out := make([]float64, len(xx))
+	for i, x := range xx {
+		out[i] = float64(x)
+	}
+	return out
+}
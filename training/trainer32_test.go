@@ -0,0 +1,147 @@
+package training
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+
+	deep "github.com/Maxime2/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadClassification reads a CSV file of examples whose first column is a
+// class label, numbered from offset, and the remaining columns are features
+func loadClassification(t *testing.T, path string, classes, offset int) Examples {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		t.Skipf("%s not found", path)
+	}
+	assert.Nil(t, err)
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	assert.Nil(t, err)
+
+	examples := make(Examples, len(records))
+	for i, record := range records {
+		values := make([]float64, len(record))
+		for j := range record {
+			values[j], err = strconv.ParseFloat(record[j], 64)
+			assert.Nil(t, err)
+		}
+		examples[i] = Example{Input: values[1:], Response: make([]float64, classes)}
+		examples[i].Response[int(values[0])-offset] = 1
+	}
+	return examples
+}
+
+// trainBothPrecisions trains identically initialized double and single
+// precision networks on train and returns their accuracies on test
+func trainBothPrecisions(c *deep.Config, train, test Examples, batchSize, iterations int) (float64, float64) {
+	n := deep.NewNeural(c)
+	n32 := n.Float32()
+
+	NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, batchSize, 1, WithSeed(1)).Train(n, train, nil, iterations)
+	NewTrainer32(NewAdam(0.01, 0, 0, 0), 0, batchSize, WithSeed(1)).Train(n32, train, nil, iterations)
+
+	return accuracy(n, test), accuracy(n32.Float64(), test)
+}
+
+func Test_Float32Blobs(t *testing.T) {
+	acc, acc32 := trainBothPrecisions(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	}, blobs(200, 1), blobs(100, 2), 20, 50)
+
+	assert.True(t, acc > 0.9)
+	assert.InDelta(t, acc, acc32, 0.01)
+}
+
+func Test_Float32Options(t *testing.T) {
+	assert.NotPanics(t, func() { NewTrainer32(NewAdam(0.01, 0, 0, 0), 0, 20, WithSeed(1)) })
+	assert.Panics(t, func() { NewTrainer32(NewAdam(0.01, 0, 0, 0), 0, 20, WithPruning(Pruning{Final: 0.5, End: 2})) })
+	assert.Panics(t, func() { NewTrainer32(NewAdam(0.01, 0, 0, 0), 0, 20, WithPrivacy(Privacy{Clip: 1})) })
+}
+
+func Test_Float32Wines(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	for i := range data {
+		deep.Standardize(data[i].Input)
+	}
+
+	acc, acc32 := trainBothPrecisions(&deep.Config{
+		Inputs:     len(data[0].Input),
+		Layout:     []int{8, 3},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Init:       &deep.Initializer{Kind: "glorot_uniform"},
+		Bias:       true,
+		Seed:       1,
+	}, data, data, 16, 600)
+
+	assert.True(t, acc > 0.9)
+	assert.InDelta(t, acc, acc32, 0.01)
+}
+
+func Test_Float32MNIST(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping MNIST in short mode")
+	}
+	train := loadClassification(t, "../examples/mnist/mnist_train.data", 10, 0)
+	test := loadClassification(t, "../examples/mnist/mnist_test.data", 10, 0)
+	for _, examples := range []Examples{train, test} {
+		for i := range examples {
+			for j := range examples[i].Input {
+				examples[i].Input[j] /= 255
+			}
+		}
+	}
+
+	acc, acc32 := trainBothPrecisions(&deep.Config{
+		Inputs:     len(train[0].Input),
+		Layout:     []int{50, 10},
+		Activation: deep.ActivationReLU,
+		Mode:       deep.ModeMultiClass,
+		Init:       &deep.Initializer{Kind: "he"},
+		Bias:       true,
+		Seed:       1,
+	}, train, test, 200, 5)
+
+	assert.True(t, acc > 0.9)
+	assert.True(t, math.Abs(acc-acc32) < 0.01)
+}
+
+func Test_Float32Pruned(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+		Seed:       1,
+	})
+	deep.PruneGlobal(n, 0.5)
+	masks := n.Masks()
+
+	n32 := n.Float32()
+	NewTrainer32(NewAdam(0.01, 0, 0, 0), 0, 20, WithSeed(1)).Train(n32, blobs(200, 1), nil, 20)
+	trained := n32.Float64()
+
+	assert.Equal(t, masks, trained.Masks())
+	assert.InDelta(t, n.Sparsity(), trained.Sparsity(), 1e-9)
+	for i, l := range trained.Weights() {
+		for j, ws := range l {
+			for k, w := range ws {
+				if masks[i][j][k] {
+					assert.Equal(t, 0.0, w)
+				}
+			}
+		}
+	}
+}
@@ -73,11 +73,11 @@ func Test_Training(t *testing.T) {
 	rand.Seed(0)
 
 	data := Examples{
-		Example{[]float64{0}, []float64{0}},
-		Example{[]float64{0}, []float64{0}},
-		Example{[]float64{0}, []float64{0}},
-		Example{[]float64{5}, []float64{1}},
-		Example{[]float64{5}, []float64{1}},
+		Example{Input: []float64{0}, Response: []float64{0}},
+		Example{Input: []float64{0}, Response: []float64{0}},
+		Example{Input: []float64{0}, Response: []float64{0}},
+		Example{Input: []float64{5}, Response: []float64{1}},
+		Example{Input: []float64{5}, Response: []float64{1}},
 	}
 
 	n := deep.NewNeural(&deep.Config{
@@ -98,16 +98,16 @@ func Test_Training(t *testing.T) {
 }
 
 var data = []Example{
-	{[]float64{2.7810836, 2.550537003}, []float64{0}},
-	{[]float64{1.465489372, 2.362125076}, []float64{0}},
-	{[]float64{3.396561688, 4.400293529}, []float64{0}},
-	{[]float64{1.38807019, 1.850220317}, []float64{0}},
-	{[]float64{3.06407232, 3.005305973}, []float64{0}},
-	{[]float64{7.627531214, 2.759262235}, []float64{1}},
-	{[]float64{5.332441248, 2.088626775}, []float64{1}},
-	{[]float64{6.922596716, 1.77106367}, []float64{1}},
-	{[]float64{8.675418651, -0.242068655}, []float64{1}},
-	{[]float64{7.673756466, 3.508563011}, []float64{1}},
+	{Input: []float64{2.7810836, 2.550537003}, Response: []float64{0}},
+	{Input: []float64{1.465489372, 2.362125076}, Response: []float64{0}},
+	{Input: []float64{3.396561688, 4.400293529}, Response: []float64{0}},
+	{Input: []float64{1.38807019, 1.850220317}, Response: []float64{0}},
+	{Input: []float64{3.06407232, 3.005305973}, Response: []float64{0}},
+	{Input: []float64{7.627531214, 2.759262235}, Response: []float64{1}},
+	{Input: []float64{5.332441248, 2.088626775}, Response: []float64{1}},
+	{Input: []float64{6.922596716, 1.77106367}, Response: []float64{1}},
+	{Input: []float64{8.675418651, -0.242068655}, Response: []float64{1}},
+	{Input: []float64{7.673756466, 3.508563011}, Response: []float64{1}},
 }
 
 func Test_Prediction(t *testing.T) {
@@ -150,16 +150,16 @@ func Test_CrossVal(t *testing.T) {
 
 func Test_MultiClass(t *testing.T) {
 	var data = []Example{
-		{[]float64{2.7810836, 2.550537003}, []float64{1, 0}},
-		{[]float64{1.465489372, 2.362125076}, []float64{1, 0}},
-		{[]float64{3.396561688, 4.400293529}, []float64{1, 0}},
-		{[]float64{1.38807019, 1.850220317}, []float64{1, 0}},
-		{[]float64{3.06407232, 3.005305973}, []float64{1, 0}},
-		{[]float64{7.627531214, 2.759262235}, []float64{0, 1}},
-		{[]float64{5.332441248, 2.088626775}, []float64{0, 1}},
-		{[]float64{6.922596716, 1.77106367}, []float64{0, 1}},
-		{[]float64{8.675418651, -0.242068655}, []float64{0, 1}},
-		{[]float64{7.673756466, 3.508563011}, []float64{0, 1}},
+		{Input: []float64{2.7810836, 2.550537003}, Response: []float64{1, 0}},
+		{Input: []float64{1.465489372, 2.362125076}, Response: []float64{1, 0}},
+		{Input: []float64{3.396561688, 4.400293529}, Response: []float64{1, 0}},
+		{Input: []float64{1.38807019, 1.850220317}, Response: []float64{1, 0}},
+		{Input: []float64{3.06407232, 3.005305973}, Response: []float64{1, 0}},
+		{Input: []float64{7.627531214, 2.759262235}, Response: []float64{0, 1}},
+		{Input: []float64{5.332441248, 2.088626775}, Response: []float64{0, 1}},
+		{Input: []float64{6.922596716, 1.77106367}, Response: []float64{0, 1}},
+		{Input: []float64{8.675418651, -0.242068655}, Response: []float64{0, 1}},
+		{Input: []float64{7.673756466, 3.508563011}, Response: []float64{0, 1}},
 	}
 
 	n := deep.NewNeural(&deep.Config{
@@ -199,10 +199,10 @@ func Test_or(t *testing.T) {
 		Bias:       true,
 	})
 	permutations := Examples{
-		{[]float64{0, 0}, []float64{0}},
-		{[]float64{1, 0}, []float64{1}},
-		{[]float64{0, 1}, []float64{1}},
-		{[]float64{1, 1}, []float64{1}},
+		{Input: []float64{0, 0}, Response: []float64{0}},
+		{Input: []float64{1, 0}, Response: []float64{1}},
+		{Input: []float64{0, 1}, Response: []float64{1}},
+		{Input: []float64{1, 1}, Response: []float64{1}},
 	}
 
 	trainer := NewTrainer(NewSGD(0.5, 0, 0, false), 10)
@@ -225,10 +225,10 @@ func Test_xor(t *testing.T) {
 		Bias:       true,
 	})
 	permutations := Examples{
-		{[]float64{0, 0}, []float64{0}},
-		{[]float64{1, 0}, []float64{1}},
-		{[]float64{0, 1}, []float64{1}},
-		{[]float64{1, 1}, []float64{0}},
+		{Input: []float64{0, 0}, Response: []float64{0}},
+		{Input: []float64{1, 0}, Response: []float64{1}},
+		{Input: []float64{0, 1}, Response: []float64{1}},
+		{Input: []float64{1, 1}, Response: []float64{0}},
 	}
 
 	trainer := NewTrainer(NewSGD(1.0, 0.1, 1e-6, false), 50)
@@ -239,6 +239,233 @@ func Test_xor(t *testing.T) {
 	}
 }
 
+func Test_MixtureDensity(t *testing.T) {
+	rand.Seed(0)
+
+	data := Examples{}
+	for i := 0; i < 500; i++ {
+		x := rand.Float64()
+		y := x + 0.05*rand.NormFloat64()
+		if i%2 == 0 {
+			y = -y
+		}
+		data = append(data, Example{Input: []float64{x}, Response: []float64{y}})
+	}
+
+	// The loss is defined per example, so that it trains online as well
+	for _, trainer := range []Trainer{
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 50, 2),
+		NewTrainer(NewAdam(0.001, 0, 0, 0), 0, WithSeed(1)),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     1,
+			Layout:     []int{8, deep.MixtureOutputs(2, 1)},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMixtureDensity,
+			Components: 2,
+			Weight:     deep.NewUniform(0.5, 0),
+			Bias:       true,
+		})
+		trainer.Train(n, append(Examples{}, data...), nil, 300)
+
+		for _, x := range []float64{0.3, 0.5, 0.8} {
+			m := n.PredictDistribution([]float64{x})
+			lo, hi := 0, 1
+			if m.Means[lo][0] > m.Means[hi][0] {
+				lo, hi = hi, lo
+			}
+			assert.InDelta(t, 0.5, m.Weights[lo], 0.15)
+			assert.InDelta(t, -x, m.Means[lo][0], 0.15)
+			assert.InDelta(t, x, m.Means[hi][0], 0.15)
+			assert.InDelta(t, 0, m.Mean()[0], 0.15)
+		}
+	}
+}
+
+func Test_SampleWeights(t *testing.T) {
+	// Conflicting responses, the second weighing three times the first, and
+	// the third nothing at all
+	data := Examples{
+		{Input: []float64{1}, Response: []float64{0}},
+		Example{Input: []float64{1}, Response: []float64{1}}.Weighted(3),
+		Example{Input: []float64{1}, Response: []float64{10}}.Weighted(0),
+	}
+	assert.Equal(t, []float64{1, 3, 0}, []float64{data[0].EffectiveWeight(), data[1].EffectiveWeight(), data[2].EffectiveWeight()})
+
+	for _, trainer := range []Trainer{
+		NewTrainer(NewSGD(0.01, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.01, 0, 0, false), 0, 3, 1),
+	} {
+		rand.Seed(0)
+		n := deep.NewNeural(&deep.Config{
+			Inputs: 1,
+			Layout: []int{1},
+			Mode:   deep.ModeRegression,
+			Weight: deep.NewUniform(0.5, 0),
+		})
+		trainer.Train(n, data, nil, 2000)
+
+		assert.InDelta(t, 0.75, n.Predict([]float64{1})[0], 0.01)
+	}
+}
+
+func Test_MaskedTargets(t *testing.T) {
+	data := Examples{
+		{Input: []float64{0, 1}, Response: []float64{1, 0}, Mask: []bool{true, false}},
+		{Input: []float64{1, 0}, Response: []float64{0, 1}, Mask: []bool{true, false}},
+	}
+
+	for _, trainer := range []Trainer{
+		NewTrainer(NewSGD(0.5, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.5, 0, 0, false), 0, 2, 2),
+	} {
+		rand.Seed(0)
+		n := deep.NewNeural(&deep.Config{
+			Inputs: 2,
+			Layout: []int{3, 2},
+			Mode:   deep.ModeMultiLabel,
+			Weight: deep.NewUniform(0.5, 0),
+			Bias:   true,
+		})
+		before := n.Weights()[1][1]
+		trainer.Train(n, data, nil, 100)
+
+		// The unlabelled output receives no gradient at all
+		assert.Equal(t, before, n.Weights()[1][1])
+		assert.NotEqual(t, n.Predict([]float64{0, 1})[0], n.Predict([]float64{1, 0})[0])
+	}
+}
+
+func Test_OnlineTrainerReproducible(t *testing.T) {
+	train := func(src rand.Source) [][][]float64 {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{3, 1},
+			Activation: deep.ActivationSigmoid,
+			Bias:       true,
+			Seed:       42,
+		})
+		trainer := NewTrainer(NewSGD(0.5, 0.1, 0, false), 0, WithSource(src))
+		trainer.Train(n, append(Examples{}, data...), nil, 20)
+		return n.Weights()
+	}
+
+	assert.Equal(t, train(rand.NewSource(1)), train(rand.NewSource(1)))
+	assert.NotEqual(t, train(rand.NewSource(1)), train(rand.NewSource(2)))
+}
+
+func Test_FakeQuantization(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+
+	for _, trainer := range []Trainer{
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1), WithFakeQuantization(deep.PerLayer)),
+		NewTrainer(NewAdam(0.001, 0, 0, 0), 0, WithSeed(1), WithFakeQuantization(deep.PerLayer)),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     len(data[0].Input),
+			Layout:     []int{8, 3},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Init:       &deep.Initializer{Kind: "glorot_uniform"},
+			Bias:       true,
+			Seed:       1,
+		})
+		trainer.Train(n, data, nil, 50)
+
+		q := deep.Quantize(n, data.Inputs(), deep.PerLayer)
+		correct := 0
+		for _, e := range data {
+			if deep.ArgMax(q.Predict(e.Input)) == deep.ArgMax(e.Response) {
+				correct++
+			}
+		}
+		assert.True(t, float64(correct)/float64(len(data)) > 0.9)
+	}
+}
+
+func Test_GradualPruning(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+	pruning := Pruning{Final: 0.8, Begin: 5, End: 25, Frequency: 5, Global: true}
+
+	for _, trainer := range []Trainer{
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1), WithPruning(pruning)),
+		NewTrainer(NewAdam(0.001, 0, 0, 0), 0, WithSeed(1), WithPruning(pruning)),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     len(data[0].Input),
+			Layout:     []int{8, 3},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Init:       &deep.Initializer{Kind: "glorot_uniform"},
+			Bias:       true,
+			Seed:       1,
+		})
+		trainer.Train(n, data, nil, 50)
+		assert.InDelta(t, 0.8, n.Sparsity(), 0.01)
+
+		for _, l := range n.Layers {
+			for _, neuron := range l.Neurons {
+				for _, s := range neuron.In {
+					if s.Pruned {
+						assert.Equal(t, 0.0, s.Weight)
+					}
+				}
+			}
+		}
+
+		correct := 0
+		for _, e := range data {
+			if deep.ArgMax(n.Predict(e.Input)) == deep.ArgMax(e.Response) {
+				correct++
+			}
+		}
+		assert.True(t, float64(correct)/float64(len(data)) > 0.9)
+	}
+
+	assert.Equal(t, 0.0, pruning.Sparsity(0))
+	assert.InDelta(t, 0.8*(1-math.Pow(0.5, 3)), pruning.Sparsity(15), 1e-9)
+	assert.Equal(t, 0.8, pruning.Sparsity(40))
+}
+
+func Test_StructuredPruning(t *testing.T) {
+	data := loadClassification(t, "../examples/wines/wine.data", 3, 1)
+	standardizeFeatures(data)
+	pruning := Pruning{Final: 0.5, Begin: 5, End: 25, Frequency: 5, Neurons: true}
+
+	for _, trainer := range []Trainer{
+		NewBatchTrainer(NewAdam(0.01, 0, 0, 0), 0, 16, 2, WithSeed(1), WithPruning(pruning)),
+		NewTrainer(NewAdam(0.001, 0, 0, 0), 0, WithSeed(1), WithPruning(pruning)),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     len(data[0].Input),
+			Layout:     []int{16, 3},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeMultiClass,
+			Init:       &deep.Initializer{Kind: "glorot_uniform"},
+			Bias:       true,
+			Seed:       1,
+		})
+		trainer.Train(n, data, nil, 50)
+
+		// Masked neurons are removed without changing predictions
+		masked := deep.FromDump(n.Dump())
+		deep.RemoveMaskedNeurons(n)
+		assert.Equal(t, []int{8, 3}, n.Config.Layout)
+		removed := deep.FromDump(n.Dump())
+		correct := 0
+		for _, e := range data {
+			prediction := removed.Predict(e.Input)
+			assert.InDeltaSlice(t, masked.Predict(e.Input), prediction, 1e-12)
+			if deep.ArgMax(prediction) == deep.ArgMax(e.Response) {
+				correct++
+			}
+		}
+		assert.True(t, float64(correct)/float64(len(data)) > 0.9)
+	}
+}
+
 func printResult(ideal, actual []float64) {
 	fmt.Printf("want: %+v have: %+v\n", ideal, actual)
 }
@@ -1,27 +1,193 @@
 package deep
 
-import "math/rand"
+import (
+	"math"
+	"math/rand"
+)
 
-// A WeightInitializer returns a (random) weight
-type WeightInitializer func() float64
+// A WeightInitializer returns a (random) weight drawn from r for a synapse
+// feeding a layer of fanOut neurons, each of which is fed by fanIn inputs
+type WeightInitializer func(r *rand.Rand, fanIn, fanOut int) float64
 
 // NewUniform returns a uniform weight generator
 func NewUniform(stdDev, mean float64) WeightInitializer {
-	return func() float64 { return Uniform(stdDev, mean) }
+	return func(r *rand.Rand, _, _ int) float64 { return uniform(r, stdDev, mean) }
 }
 
 // Uniform samples a value from u(mean-stdDev/2,mean+stdDev/2)
 func Uniform(stdDev, mean float64) float64 {
-	return (rand.Float64()-0.5)*stdDev + mean
+	return uniform(globalRand, stdDev, mean)
+}
 
+func uniform(r *rand.Rand, stdDev, mean float64) float64 {
+	return (r.Float64()-0.5)*stdDev + mean
 }
 
 // NewNormal returns a normal weight generator
 func NewNormal(stdDev, mean float64) WeightInitializer {
-	return func() float64 { return Normal(stdDev, mean) }
+	return func(r *rand.Rand, _, _ int) float64 { return normal(r, stdDev, mean) }
 }
 
 // Normal samples a value from N(μ, σ)
 func Normal(stdDev, mean float64) float64 {
-	return rand.NormFloat64()*stdDev + mean
+	return normal(globalRand, stdDev, mean)
+}
+
+func normal(r *rand.Rand, stdDev, mean float64) float64 {
+	return r.NormFloat64()*stdDev + mean
+}
+
+// globalRand draws from the global math/rand source
+var globalRand = rand.New(globalSource{})
+
+type globalSource struct{}
+
+func (globalSource) Int63() int64    { return rand.Int63() }
+func (globalSource) Uint64() uint64  { return rand.Uint64() }
+func (globalSource) Seed(seed int64) { rand.Seed(seed) }
+
+// NewRand returns a random number generator seeded with seed, or one drawing
+// from the global math/rand source when seed is zero
+func NewRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		return globalRand
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewGlorotUniform returns a Glorot (Xavier) uniform weight generator,
+// sampling from u(-√(6/(fanIn+fanOut)), √(6/(fanIn+fanOut)))
+func NewGlorotUniform() WeightInitializer {
+	return func(r *rand.Rand, fanIn, fanOut int) float64 {
+		return uniform(r, 2*math.Sqrt(6/float64(fanIn+fanOut)), 0)
+	}
+}
+
+// NewGlorotNormal returns a Glorot (Xavier) normal weight generator,
+// sampling from N(0, √(2/(fanIn+fanOut)))
+func NewGlorotNormal() WeightInitializer {
+	return func(r *rand.Rand, fanIn, fanOut int) float64 {
+		return normal(r, math.Sqrt(2/float64(fanIn+fanOut)), 0)
+	}
+}
+
+// NewHe returns a He (Kaiming) normal weight generator suited to ReLU,
+// sampling from N(0, √(2/fanIn))
+func NewHe() WeightInitializer {
+	return func(r *rand.Rand, fanIn, _ int) float64 {
+		return normal(r, math.Sqrt(2/float64(fanIn)), 0)
+	}
+}
+
+// NewLeCun returns a LeCun normal weight generator, sampling from N(0, √(1/fanIn))
+func NewLeCun() WeightInitializer {
+	return func(r *rand.Rand, fanIn, _ int) float64 {
+		return normal(r, math.Sqrt(1/float64(fanIn)), 0)
+	}
+}
+
+// InitOrthogonal replaces the weights feeding every layer of n from the
+// previous layer, or from the inputs, with a random (semi-)orthogonal matrix
+// scaled by gain. Recurrent and bias weights are left as they are.
+func InitOrthogonal(n *Neural, gain float64) {
+	fanIn := n.Config.Inputs
+	for _, l := range n.Layers {
+		w := orthogonal(n.random(), len(l.Neurons), fanIn)
+		for j, neuron := range l.Neurons {
+			for k := 0; k < fanIn; k++ {
+				neuron.In[k].Weight = gain * w[j][k]
+			}
+		}
+		fanIn = len(l.Neurons)
+	}
+}
+
+// orthogonal returns a random rows×cols matrix with orthonormal rows or
+// columns, whichever are fewer
+func orthogonal(r *rand.Rand, rows, cols int) [][]float64 {
+	k, m := rows, cols
+	if rows > cols {
+		k, m = cols, rows
+	}
+
+	// Gram-Schmidt orthonormalization of k gaussian vectors in m dimensions
+	vs := make([][]float64, k)
+	for i := range vs {
+		for {
+			vs[i] = make([]float64, m)
+			for d := range vs[i] {
+				vs[i][d] = r.NormFloat64()
+			}
+			for _, u := range vs[:i] {
+				p := Dot(vs[i], u)
+				for d := range vs[i] {
+					vs[i][d] -= p * u[d]
+				}
+			}
+			if norm := math.Sqrt(Dot(vs[i], vs[i])); norm > 1e-8 {
+				for d := range vs[i] {
+					vs[i][d] /= norm
+				}
+				break
+			}
+		}
+	}
+
+	if rows <= cols {
+		return vs
+	}
+	w := make([][]float64, rows)
+	for r := range w {
+		w[r] = make([]float64, cols)
+		for c := range w[r] {
+			w[r][c] = vs[c][r]
+		}
+	}
+	return w
+}
+
+// InitLSUV applies layer-sequential unit-variance initialization to n: layer by
+// layer, the feed-forward weights feeding it are rescaled until the variance
+// of its pre-activations over the sample batch is within tol of 1, or until
+// maxIter rescalings. Bias and recurrent weights are left as they are, and
+// every sample is forwarded without recurrent state, which is restored after
+// calibration. It is meant to follow an orthogonal initialization.
+func InitLSUV(n *Neural, batch [][]float64, tol float64, maxIter int) {
+	state := n.RecurrentState()
+	defer n.SetRecurrentState(state)
+	zero := make([]float64, len(state))
+
+	for i, l := range n.Layers {
+		hidden := i < len(n.Layers)-1
+		for it := 0; it < maxIter; it++ {
+			var values []float64
+			for _, x := range batch {
+				n.SetRecurrentState(zero)
+				n.Forward(x)
+				for _, neuron := range l.Neurons {
+					var sum float64
+					for _, s := range neuron.In {
+						sum += s.Out
+					}
+					values = append(values, sum)
+				}
+			}
+			variance := Variance(values)
+			if math.Abs(variance-1) < tol || variance == 0 {
+				break
+			}
+			scale := 1 / math.Sqrt(variance)
+			for _, neuron := range l.Neurons {
+				var recurrent *Synapse
+				if hidden {
+					recurrent = neuron.Out[len(neuron.Out)-1]
+				}
+				for _, s := range neuron.In {
+					if !s.IsBias && s != recurrent {
+						s.Weight *= scale
+					}
+				}
+			}
+		}
+	}
 }
@@ -0,0 +1,145 @@
+package deep
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ShapedInitializers(t *testing.T) {
+	rand.Seed(0)
+
+	tests := []struct {
+		init   WeightInitializer
+		stdDev float64
+		bound  float64
+	}{
+		{init: NewGlorotUniform(), stdDev: math.Sqrt(2.0 / 30), bound: math.Sqrt(6.0 / 30)},
+		{init: NewGlorotNormal(), stdDev: math.Sqrt(2.0 / 30)},
+		{init: NewHe(), stdDev: math.Sqrt(2.0 / 10)},
+		{init: NewLeCun(), stdDev: math.Sqrt(1.0 / 10)},
+	}
+	for _, test := range tests {
+		ws := make([]float64, 10000)
+		for i := range ws {
+			ws[i] = test.init(globalRand, 10, 20)
+			if test.bound > 0 {
+				assert.True(t, math.Abs(ws[i]) <= test.bound)
+			}
+		}
+		assert.InDelta(t, 0, Mean(ws), 0.01)
+		assert.InEpsilon(t, test.stdDev, StandardDeviation(ws), 0.05)
+	}
+}
+
+func Test_InitializerShapes(t *testing.T) {
+	shapes := map[[2]int]int{}
+	NewNeural(&Config{
+		Inputs: 3,
+		Layout: []int{4, 2},
+		Weight: func(_ *rand.Rand, fanIn, fanOut int) float64 {
+			shapes[[2]int{fanIn, fanOut}]++
+			return 0
+		},
+		Bias: true,
+	})
+
+	assert.Equal(t, map[[2]int]int{
+		{3, 4}: 3*4 + 4, // Inputs and bias
+		{4, 4}: 4,       // Recurrent
+		{4, 2}: 4*2 + 2, // Hidden and bias
+	}, shapes)
+}
+
+func Test_InitOrthogonal(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&Config{
+		Inputs: 5,
+		Layout: []int{3, 8},
+		Bias:   true,
+	})
+	InitOrthogonal(n, 2)
+
+	// Rows feeding the 3 neurons of the first layer are orthogonal
+	w := n.Weights()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			expected := 0.0
+			if i == j {
+				expected = 4
+			}
+			assert.InDelta(t, expected, Dot(w[0][i][:5], w[0][j][:5]), 1e-9)
+		}
+	}
+	// Columns fed by the 3 neurons into the 8 neurons of the second are orthogonal
+	for a := 0; a < 3; a++ {
+		for b := 0; b < 3; b++ {
+			var dot float64
+			for j := 0; j < 8; j++ {
+				dot += w[1][j][a] * w[1][j][b]
+			}
+			expected := 0.0
+			if a == b {
+				expected = 4
+			}
+			assert.InDelta(t, expected, dot, 1e-9)
+		}
+	}
+}
+
+func Test_InitLSUV(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&Config{
+		Inputs:     10,
+		Layout:     []int{20, 20, 1},
+		Activation: ActivationTanh,
+		Weight:     NewUniform(5, 0),
+	})
+	batch := make([][]float64, 100)
+	for i := range batch {
+		batch[i] = make([]float64, 10)
+		for j := range batch[i] {
+			batch[i][j] = rand.NormFloat64()
+		}
+	}
+
+	InitOrthogonal(n, 1)
+	n.Forward(batch[0])
+	state := n.RecurrentState()
+	var recurrent []float64
+	for _, l := range n.Layers[:len(n.Layers)-1] {
+		for _, neuron := range l.Neurons {
+			recurrent = append(recurrent, neuron.Out[len(neuron.Out)-1].Weight)
+		}
+	}
+
+	InitLSUV(n, batch, 0.01, 10)
+
+	assert.Equal(t, state, n.RecurrentState())
+	var after []float64
+	for _, l := range n.Layers[:len(n.Layers)-1] {
+		for _, neuron := range l.Neurons {
+			after = append(after, neuron.Out[len(neuron.Out)-1].Weight)
+		}
+	}
+	assert.Equal(t, recurrent, after)
+
+	zero := make([]float64, len(state))
+	for _, l := range n.Layers {
+		var values []float64
+		for _, x := range batch {
+			n.SetRecurrentState(zero)
+			n.Forward(x)
+			for _, neuron := range l.Neurons {
+				var sum float64
+				for _, s := range neuron.In {
+					sum += s.Out
+				}
+				values = append(values, sum)
+			}
+		}
+		assert.InDelta(t, 1, Variance(values), 0.1)
+	}
+}